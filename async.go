@@ -0,0 +1,192 @@
+package ctxlog
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncPolicy controls what AsyncWriter does when its queue is full.
+type AsyncPolicy int
+
+const (
+	// AsyncBlock blocks the logging goroutine until the queue has room.
+	// No entry is lost, but the logging slows down to the speed of the output.
+	AsyncBlock AsyncPolicy = iota
+
+	// AsyncDrop drops the entry, and counts it in AsyncWriter.Dropped.
+	// The logging never waits for the output, but the entries are lost under the sustained load.
+	AsyncDrop
+)
+
+// ErrAsyncClosed is returned by the writes to a closed AsyncWriter.
+var ErrAsyncClosed = errors.New("ctxlog: write to closed AsyncWriter")
+
+// AsyncWriter writes the entries to an output in a background goroutine,
+// so that the logging goroutines don't wait for the I/O.
+// The entries are queued to a buffered channel, and written in batches.
+//
+// The queued entries are lost if the process exits without Flush or Close.
+// Logger.Close closes the writer, and the Fatal variants of the logger flush it before exiting.
+type AsyncWriter struct {
+	w      io.Writer
+	policy AsyncPolicy
+
+	mu      sync.RWMutex // protects closed, and prevents the sends to the closed queue
+	closed  bool
+	queue   chan asyncItem
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	errMu sync.Mutex
+	err   error // the first error of writing to w since the last Flush
+}
+
+type asyncItem struct {
+	p       []byte
+	flushed chan error // not nil for the requests of Flush
+}
+
+var _ io.WriteCloser = (*AsyncWriter)(nil)
+
+// NewAsyncWriter returns a new AsyncWriter that writes to w.
+// bufSize is the number of the entries that the queue holds,
+// and policy controls the writes when the queue is full.
+func NewAsyncWriter(w io.Writer, bufSize int, policy AsyncPolicy) *AsyncWriter {
+	if bufSize < 0 {
+		bufSize = 0
+	}
+	aw := &AsyncWriter{
+		w:      w,
+		policy: policy,
+		queue:  make(chan asyncItem, bufSize),
+		done:   make(chan struct{}),
+	}
+	go aw.run()
+	return aw
+}
+
+// NewAsync returns a new Logger that writes to out asynchronously,
+// with the queue of bufSize entries and the AsyncBlock policy.
+// Use SetOutput with NewAsyncWriter for the other policies.
+// Close the logger to write the queued entries and stop the background goroutine.
+func NewAsync(out io.Writer, prefix string, flag int, bufSize int) *Logger {
+	return New(NewAsyncWriter(out, bufSize, AsyncBlock), prefix, flag)
+}
+
+// Write implements io.Writer.
+// p is copied, and written to the output later.
+// The errors of writing to the output are reported by Flush and Close.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return 0, ErrAsyncClosed
+	}
+
+	item := asyncItem{p: append([]byte(nil), p...)}
+	if w.policy == AsyncDrop {
+		select {
+		case w.queue <- item:
+		default:
+			w.dropped.Add(1)
+		}
+		return len(p), nil
+	}
+	w.queue <- item
+	return len(p), nil
+}
+
+// Dropped returns the number of the entries dropped by the AsyncDrop policy.
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Flush waits for the entries queued before the call to be written,
+// and flushes the output if it has a Flush method.
+// It returns the first error of writing to the output since the last Flush.
+func (w *AsyncWriter) Flush() error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan error, 1)
+	w.queue <- asyncItem{flushed: flushed}
+	w.mu.RUnlock()
+	return <-flushed
+}
+
+// Close writes the queued entries, stops the background goroutine,
+// and then flushes and closes the output except os.Stdout and os.Stderr.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	<-w.done
+	err := w.takeErr()
+	if cerr := closeOutput(w.w); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	var buf []byte
+	for item := range w.queue {
+		buf = append(buf[:0], item.p...)
+
+		// batch the entries that are already queued.
+	batch:
+		for item.flushed == nil {
+			select {
+			case next, ok := <-w.queue:
+				if !ok {
+					break batch
+				}
+				item = next
+				buf = append(buf, item.p...)
+			default:
+				break batch
+			}
+		}
+
+		if len(buf) > 0 {
+			if _, err := w.w.Write(buf); err != nil {
+				w.setErr(err)
+			}
+		}
+		if item.flushed != nil {
+			if f, ok := w.w.(interface{ Flush() error }); ok {
+				if err := f.Flush(); err != nil {
+					w.setErr(err)
+				}
+			}
+			item.flushed <- w.takeErr()
+		}
+	}
+}
+
+func (w *AsyncWriter) setErr(err error) {
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *AsyncWriter) takeErr() error {
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	err := w.err
+	w.err = nil
+	return err
+}
@@ -0,0 +1,135 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+// lockedBuffer is a bytes.Buffer that is safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// blockingWriter blocks the writes until unblock is closed.
+type blockingWriter struct {
+	lockedBuffer
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	return w.lockedBuffer.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	buf := new(lockedBuffer)
+	l := NewAsync(buf, "", 0, 16)
+	ctx := context.Background()
+
+	var want string
+	for i := 0; i < 100; i++ {
+		msg := fmt.Sprintf("%d", i)
+		l.Info(ctx, msg, nil)
+		want += `{"level":"info","message":"` + msg + "\"}\n"
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w := l.Writer().(*AsyncWriter)
+	if _, err := w.Write([]byte("closed\n")); !errors.Is(err, ErrAsyncClosed) {
+		t.Errorf("want ErrAsyncClosed, got %v", err)
+	}
+}
+
+func TestAsyncWriter_Drop(t *testing.T) {
+	out := &blockingWriter{unblock: make(chan struct{})}
+	w := NewAsyncWriter(out, 1, AsyncDrop)
+
+	// the background goroutine is blocked by the first batch, and the queue holds only one entry,
+	// so the rest are dropped.
+	for i := 0; i < 10; i++ {
+		if _, err := w.Write([]byte("entry\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Dropped() == 0 {
+		t.Error("want some entries dropped, got none")
+	}
+
+	close(out.unblock)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Count([]byte(out.String()), []byte("\n"))
+	if uint64(lines)+w.Dropped() != 10 {
+		t.Errorf("unexpected output: got %d lines, %d dropped", lines, w.Dropped())
+	}
+}
+
+func TestAsyncWriter_Error(t *testing.T) {
+	w := NewAsyncWriter(errWriter{}, 1, AsyncBlock)
+	if _, err := w.Write([]byte("entry\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err == nil {
+		t.Error("want an error, got nil")
+	}
+	if err := w.Flush(); err != nil {
+		t.Errorf("want the error reported once, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+func TestAsyncWriter_Fatal(t *testing.T) {
+	var code int
+	osExit = func(c int) { code = c }
+	defer func() { osExit = os.Exit }()
+
+	buf := new(lockedBuffer)
+	l := NewAsync(buf, "", 0, 16)
+	defer l.Close()
+
+	// the child flushes the output shared with l.
+	child := l.With(Fields{"component": "main"})
+	child.FatalContext(context.Background(), "fatal", nil)
+	if code != 1 {
+		t.Errorf("unexpected exit code: got %d, want 1", code)
+	}
+	want := `{"level":"fatal","message":"fatal","component":"main"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
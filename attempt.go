@@ -0,0 +1,35 @@
+package ctxlog
+
+import "context"
+
+var keyAttempt = &ctxKey{"ctxlog-attempt"}
+
+// WithAttempt returns a copy of parent that carries n as the "attempt" field.
+// Use it in retry loops to filter the logs by retry iteration:
+//
+//	ctx = ctxlog.WithMaxAttempts(ctx, 3)
+//	for ctx := ctxlog.WithAttempt(ctx, 1); ; ctx = ctxlog.NextAttempt(ctx) {
+//		if err := do(ctx); err == nil {
+//			break
+//		}
+//	}
+func WithAttempt(parent context.Context, n int) context.Context {
+	ctx := context.WithValue(parent, keyAttempt, n)
+	return With(ctx, Fields{"attempt": n})
+}
+
+// NextAttempt returns a copy of parent whose "attempt" field is incremented.
+// If parent doesn't have the "attempt" field, the attempt starts at 1.
+func NextAttempt(parent context.Context) context.Context {
+	return WithAttempt(parent, contextAttempt(parent)+1)
+}
+
+func contextAttempt(ctx context.Context) int {
+	n, _ := ctx.Value(keyAttempt).(int)
+	return n
+}
+
+// WithMaxAttempts returns a copy of parent that carries n as the "max_attempts" field.
+func WithMaxAttempts(parent context.Context, n int) context.Context {
+	return With(parent, Fields{"max_attempts": n})
+}
@@ -0,0 +1,24 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestWithAttempt(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+
+	ctx := WithMaxAttempts(context.Background(), 3)
+	ctx = NextAttempt(ctx)
+	l.Info(ctx, "retrying", nil)
+	ctx = NextAttempt(ctx)
+	l.Info(ctx, "retrying", nil)
+
+	want := `{"level":"info","message":"retrying","attempt":1,"max_attempts":3}` + "\n" +
+		`{"level":"info","message":"retrying","attempt":2,"max_attempts":3}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
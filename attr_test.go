@@ -0,0 +1,52 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAttr(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.InfoKV(context.Background(), "hello",
+		String("string", "foo"),
+		Strings("strings", []string{"a", "b"}),
+		Int("int", -1),
+		Int64("int64", 2),
+		Uint64("uint64", 3),
+		Float64("float64", 1.5),
+		Bool("bool", true),
+		Time("at", time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)),
+		Duration("duration", 1500*time.Millisecond),
+		Err(errors.New("not found")),
+		Any("any", map[string]any{"nested": 1}),
+	)
+
+	want := `{"level":"info","message":"hello","any":{"nested":1},"at":"2001-02-03T04:05:06Z","bool":true,"duration":"1.5s","error":"not found",` +
+		`"float64":1.5,"int":-1,"int64":2,"string":"foo","strings":["a","b"],"uint64":3}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func BenchmarkAttr(b *testing.B) {
+	ctx := With(context.Background(), Fields{"parent": "hello"})
+	l := New(discard, "", LstdFlags)
+
+	b.Run("Fields", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info(ctx, "test", Fields{"string": "foobar", "number": i, "boolean": true})
+		}
+	})
+
+	b.Run("KV", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.InfoKV(ctx, "test", String("string", "foobar"), Int("number", i), Bool("boolean", true))
+		}
+	})
+}
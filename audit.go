@@ -0,0 +1,62 @@
+package ctxlog
+
+import (
+	"context"
+	"io"
+)
+
+// AuditOutput returns the output destination of the audit entries.
+// If it is not set, the audit entries are written to the output of the logger.
+func (l *Logger) AuditOutput() io.Writer {
+	l.mu.RLock()
+	w := l.auditOut
+	l.mu.RUnlock()
+	if w == nil {
+		return l.Writer()
+	}
+	return w
+}
+
+// SetAuditOutput sets the output destination of the audit entries.
+// If it is nil, which is the default, the audit entries are written to the output of the logger.
+func (l *Logger) SetAuditOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.auditOut = w
+}
+
+// AuditFormatter returns the formatter of the audit entries.
+func (l *Logger) AuditFormatter() Formatter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.auditFormat != nil {
+		return l.auditFormat
+	}
+	if l.formatter != nil {
+		return l.formatter
+	}
+	return defaultFormatter
+}
+
+// SetAuditFormatter sets the formatter of the audit entries.
+// If it is nil, which is the default, the audit entries are formatted by the formatter of the logger.
+func (l *Logger) SetAuditFormatter(f Formatter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.auditFormat = f
+}
+
+// Audit writes the output for an audit event.
+// Audit entries have different durability requirements than operational logs:
+// they bypass the level filtering and the sampling, they are never dropped by the processors
+// or truncated by SetMaxFields, and they are written to the audit output set by SetAuditOutput.
+// The entries are at info level, and they carry the "audit" field of true.
+func (l *Logger) Audit(ctx context.Context, msg string, fields Fields) error {
+	return l.emit(ctx, 2, LevelInfo, msg, []KV{{Key: "audit", Value: true}}, fields, l.AuditOutput(), l.AuditFormatter(), true)
+}
+
+// Audit writes the output for an audit event by the standard logger.
+// See Logger.Audit for details.
+func Audit(ctx context.Context, msg string, fields Fields) error {
+	return std.emit(ctx, 2, LevelInfo, msg, []KV{{Key: "audit", Value: true}}, fields, std.AuditOutput(), std.AuditFormatter(), true)
+}
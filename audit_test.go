@@ -0,0 +1,72 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetLevel(LevelDisabled)
+
+	// fall back to the main output.
+	if err := l.Audit(context.Background(), "login", Fields{"user": "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"info","message":"login","audit":true,"user":"alice"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// the audit output with its own format.
+	buf.Reset()
+	audit := new(bytes.Buffer)
+	l.SetAuditOutput(audit)
+	l.SetAuditFormatter(&TextFormatter{})
+	l.SetSampler(&CountSampler{Interval: 1 << 62, First: 0, Thereafter: 0})
+	if err := l.Audit(context.Background(), "login", Fields{"user": "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("want no output, got %q", buf.String())
+	}
+	want = "INFO login audit=true user=alice\n"
+	if got := audit.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAudit_Processors(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	var calls int
+	l.AddProcessor(func(e *Entry) bool {
+		calls++
+		return false
+	})
+	l.AddProcessor(func(e *Entry) bool {
+		calls++
+		e.Message = "processed " + e.Message
+		return true
+	})
+	l.SetMaxFields(1)
+
+	l.Info(context.Background(), "dropped", nil)
+	if buf.Len() != 0 {
+		t.Errorf("want no output, got %q", buf.String())
+	}
+
+	calls = 0
+	if err := l.Audit(context.Background(), "login", Fields{"user": "alice", "role": "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("want all the processors called, got %d calls", calls)
+	}
+	want := `{"level":"info","message":"processed login","audit":true,"role":"admin","user":"alice"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
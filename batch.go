@@ -0,0 +1,175 @@
+package ctxlog
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// LevelWriter is implemented by the outputs that need the level of the entries.
+// If the output of a logger implements LevelWriter,
+// the logger calls WriteLevel instead of Write.
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level Level, p []byte) (int, error)
+}
+
+// writeLevel writes p to w, with the level if w is a LevelWriter.
+func writeLevel(w io.Writer, level Level, p []byte) (int, error) {
+	if lw, ok := w.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.Write(p)
+}
+
+// BatchWriter buffers the entries and writes them to W in batches.
+// The buffer is flushed when any of the conditions is met:
+//
+//   - the buffer grows larger than MaxBytes
+//   - the buffer has MaxEntries entries
+//   - Interval has passed since the first entry in the buffer
+//   - the buffer has FlushLevelCount entries at FlushLevel or above
+//
+// The last condition gets error bursts written promptly,
+// while routine info logs are batched efficiently:
+//
+//	w := &ctxlog.BatchWriter{
+//		W:               conn,
+//		MaxEntries:      1000,
+//		Interval:        time.Second,
+//		FlushLevel:      ctxlog.LevelError,
+//		FlushLevelCount: 1,
+//	}
+//	defer w.Close()
+//	logger.SetOutput(w)
+//
+// The conditions with zero values are disabled.
+// The error of the flush triggered by Interval is returned by the next Write, Flush or Close.
+// It is safe for concurrent use.
+type BatchWriter struct {
+	// W is the destination of the batches.
+	W io.Writer
+
+	// MaxBytes is the maximum size of the buffer in bytes.
+	MaxBytes int
+
+	// MaxEntries is the maximum number of the entries in the buffer.
+	MaxEntries int
+
+	// Interval is the maximum duration that an entry stays in the buffer.
+	Interval time.Duration
+
+	// FlushLevel is the level of the entries counted for FlushLevelCount.
+	FlushLevel Level
+
+	// FlushLevelCount is the number of the entries at FlushLevel or above that trigger a flush.
+	FlushLevelCount int
+
+	mu         sync.Mutex
+	buf        []byte
+	entries    int
+	levelCount int
+	timer      *time.Timer
+
+	// err is the error of the flush triggered by Interval, which is not reported yet.
+	err error
+}
+
+var _ LevelWriter = (*BatchWriter)(nil)
+
+// Write implements io.Writer.
+// p is counted as an entry without level, so it doesn't trigger FlushLevelCount.
+func (w *BatchWriter) Write(p []byte) (int, error) {
+	return w.write(p, false)
+}
+
+// WriteLevel implements LevelWriter.
+func (w *BatchWriter) WriteLevel(level Level, p []byte) (int, error) {
+	return w.write(p, level >= w.FlushLevel)
+}
+
+func (w *BatchWriter) write(p []byte, countLevel bool) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	w.entries++
+	if countLevel {
+		w.levelCount++
+	}
+
+	if w.shouldFlush() {
+		// p is consumed by the batch even if the flush fails,
+		// so report it as written, not to make the callers retry it.
+		return len(p), w.takeErr(w.flush())
+	}
+	if w.Interval > 0 && w.timer == nil {
+		w.timer = time.AfterFunc(w.Interval, w.flushInterval)
+	}
+	return len(p), w.takeErr(nil)
+}
+
+// flushInterval flushes the buffer after Interval, and keeps the error for the next call.
+func (w *BatchWriter) flushInterval() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.flush(); err != nil {
+		w.err = err
+	}
+}
+
+// takeErr returns err together with the error of the flush triggered by Interval, and clears the latter.
+func (w *BatchWriter) takeErr(err error) error {
+	if w.err != nil {
+		err = errors.Join(w.err, err)
+		w.err = nil
+	}
+	return err
+}
+
+func (w *BatchWriter) shouldFlush() bool {
+	if w.MaxBytes > 0 && len(w.buf) >= w.MaxBytes {
+		return true
+	}
+	if w.MaxEntries > 0 && w.entries >= w.MaxEntries {
+		return true
+	}
+	if w.FlushLevelCount > 0 && w.levelCount >= w.FlushLevelCount {
+		return true
+	}
+	return false
+}
+
+// Flush writes the buffered entries to W.
+func (w *BatchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.takeErr(w.flush())
+}
+
+func (w *BatchWriter) flush() error {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.W.Write(w.buf)
+	w.buf = w.buf[:0]
+	w.entries = 0
+	w.levelCount = 0
+	return err
+}
+
+// Close flushes the buffered entries, and closes W if it implements io.Closer.
+func (w *BatchWriter) Close() error {
+	err := w.Flush()
+	if c, ok := w.W.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
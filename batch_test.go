@@ -0,0 +1,102 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBatchWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := &BatchWriter{
+		W:               buf,
+		MaxEntries:      3,
+		FlushLevel:      LevelError,
+		FlushLevelCount: 2,
+	}
+	l := New(w, "", 0)
+	ctx := context.Background()
+
+	// the entries are batched.
+	l.Info(ctx, "1", nil)
+	l.Info(ctx, "2", nil)
+	if buf.Len() != 0 {
+		t.Errorf("want no output, got %q", buf.String())
+	}
+
+	// MaxEntries triggers a flush.
+	l.Info(ctx, "3", nil)
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 3 {
+		t.Errorf("unexpected lines: got %d, want 3", got)
+	}
+
+	// FlushLevelCount triggers a flush.
+	buf.Reset()
+	l.Error(ctx, "4", nil)
+	if buf.Len() != 0 {
+		t.Errorf("want no output, got %q", buf.String())
+	}
+	l.Error(ctx, "5", nil)
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 2 {
+		t.Errorf("unexpected lines: got %d, want 2", got)
+	}
+
+	// Close flushes the rest.
+	buf.Reset()
+	l.Info(ctx, "6", nil)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 1 {
+		t.Errorf("unexpected lines: got %d, want 1", got)
+	}
+}
+
+func TestBatchWriter_FlushError(t *testing.T) {
+	w := &BatchWriter{
+		W:          errWriter{},
+		MaxEntries: 2,
+	}
+	p := []byte("hello\n")
+	if n, err := w.Write(p); n != len(p) || err != nil {
+		t.Errorf("got (%d, %v), want (%d, nil)", n, err, len(p))
+	}
+	if n, err := w.Write(p); n != len(p) || err == nil {
+		t.Errorf("got (%d, %v), want (%d, write error)", n, err, len(p))
+	}
+}
+
+func TestBatchWriter_IntervalFlushError(t *testing.T) {
+	w := &BatchWriter{
+		W:        errWriter{},
+		Interval: time.Millisecond,
+	}
+	p := []byte("hello\n")
+	if _, err := w.Write(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the flush triggered by Interval.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w.mu.Lock()
+		err := w.err
+		w.mu.Unlock()
+		if err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the buffer is not flushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the error is reported once by the next call.
+	if err := w.Flush(); err == nil {
+		t.Error("want the write error, got nil")
+	}
+	if err := w.Flush(); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package ctxlog
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+var (
+	buildInfoOnce sync.Once
+	buildCommit   string
+	buildTime     string
+)
+
+// readBuildInfo returns the vcs revision and time embedded in the binary.
+// The result is cached because debug.ReadBuildInfo is not cheap.
+func readBuildInfo() (commit, time string) {
+	buildInfoOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				buildCommit = s.Value
+			case "vcs.time":
+				buildTime = s.Value
+			}
+		}
+	})
+	return buildCommit, buildTime
+}
@@ -0,0 +1,38 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	// replace the cached build info with fake values.
+	readBuildInfo()
+	savedCommit, savedTime := buildCommit, buildTime
+	defer func() {
+		buildCommit, buildTime = savedCommit, savedTime
+	}()
+	buildCommit = "0123456789abcdef"
+	buildTime = "2001-02-03T04:05:06Z"
+
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lbuildinfo)
+	l.Info(context.Background(), "hello", nil)
+
+	var got struct {
+		Commit    string `json:"commit"`
+		BuildTime string `json:"build_time"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Commit != "0123456789abcdef" {
+		t.Errorf("unexpected commit: got %q, want %q", got.Commit, "0123456789abcdef")
+	}
+	if got.BuildTime != "2001-02-03T04:05:06Z" {
+		t.Errorf("unexpected build_time: got %q, want %q", got.BuildTime, "2001-02-03T04:05:06Z")
+	}
+}
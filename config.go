@@ -0,0 +1,173 @@
+package ctxlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of a logger loaded by WatchConfig.
+// The omitted fields keep the current configuration.
+//
+//	{
+//	  "level": "debug",
+//	  "flags": ["date", "time", "microseconds", "utc", "shortfile"],
+//	  "format": "json"
+//	}
+type Config struct {
+	// Level is the name of the level, see ParseLevel.
+	Level string `json:"level,omitempty"`
+
+	// Flags is the names of the output flags, such as "date" for Ldate and "shortfile" for Lshortfile.
+	Flags []string `json:"flags,omitempty"`
+
+	// Format is the format of the entries: "json" or "text".
+	Format string `json:"format,omitempty"`
+}
+
+var configFlags = map[string]int{
+	"date":         Ldate,
+	"time":         Ltime,
+	"microseconds": Lmicroseconds,
+	"longfile":     Llongfile,
+	"shortfile":    Lshortfile,
+	"utc":          LUTC,
+	"msgprefix":    Lmsgprefix,
+	"buildinfo":    Lbuildinfo,
+	"unixmilli":    Lunixmilli,
+	"budget":       Lbudget,
+	"fingerprint":  Lfingerprint,
+	"uptime":       Luptime,
+	"procid":       Lprocid,
+	"donereason":   Ldonereason,
+	"errorstack":   Lerrorstack,
+	"funcname":     Lfuncname,
+	"stdflags":     LstdFlags,
+}
+
+// ApplyConfig applies c to the logger atomically,
+// so that concurrent logging doesn't see a partially applied configuration.
+func (l *Logger) ApplyConfig(c *Config) error {
+	var level Level
+	if c.Level != "" {
+		var err error
+		level, err = ParseLevel(c.Level)
+		if err != nil {
+			return err
+		}
+	}
+
+	var flag int
+	for _, name := range c.Flags {
+		f, ok := configFlags[name]
+		if !ok {
+			return fmt.Errorf("ctxlog: unknown flag: %q", name)
+		}
+		flag |= f
+	}
+
+	var formatter Formatter
+	switch c.Format {
+	case "":
+	case "json":
+		formatter = &JSONFormatter{}
+	case "text":
+		formatter = &TextFormatter{}
+	default:
+		return fmt.Errorf("ctxlog: unknown format: %q", c.Format)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c.Level != "" {
+		l.level = level
+		l.ownLevel = true
+	}
+	if c.Flags != nil {
+		l.flag = flag
+		l.ownFlag = true
+	}
+	if formatter != nil {
+		l.formatter = formatter
+	}
+	return nil
+}
+
+// configPollInterval is the interval of polling the configuration file.
+var configPollInterval = time.Second
+
+// WatchConfig loads the configuration of the logger from the JSON file at path,
+// and re-applies it whenever the file changes.
+// It allows operators to change the level in production without a restart.
+// The file is polled every second, and the invalid configuration is ignored.
+//
+// Only JSON is supported; the files with the .yaml or .yml extension are rejected.
+// It returns an error if the initial configuration can't be loaded.
+// The watch stops when the logger is closed, or WatchConfig is called again.
+func (l *Logger) WatchConfig(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return fmt.Errorf("ctxlog: unsupported config format: %q, only JSON is supported", path)
+	}
+	data, err := l.loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	l.mu.Lock()
+	if l.stopWatch != nil {
+		l.stopWatch()
+	}
+	l.stopWatch = func() { close(done) }
+	l.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			newData, err := os.ReadFile(path)
+			if err != nil || bytes.Equal(data, newData) {
+				continue
+			}
+			data = newData
+			l.applyConfigData(newData)
+		}
+	}()
+	return nil
+}
+
+// WatchConfig loads the configuration of the standard logger from the JSON file at path,
+// and re-applies it whenever the file changes.
+// See Logger.WatchConfig for details.
+func WatchConfig(path string) error {
+	return std.WatchConfig(path)
+}
+
+func (l *Logger) loadConfig(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.applyConfigData(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (l *Logger) applyConfigData(data []byte) error {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("ctxlog: failed to parse the config: %w", err)
+	}
+	return l.ApplyConfig(&c)
+}
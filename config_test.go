@@ -0,0 +1,81 @@
+package ctxlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyConfig(t *testing.T) {
+	l := New(nil, "", 0)
+	err := l.ApplyConfig(&Config{
+		Level:  "warn",
+		Flags:  []string{"date", "shortfile"},
+		Format: "text",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Level(); got != LevelWarn {
+		t.Errorf("unexpected level: got %v, want %v", got, LevelWarn)
+	}
+	if got := l.Flags(); got != Ldate|Lshortfile {
+		t.Errorf("unexpected flags: got %d, want %d", got, Ldate|Lshortfile)
+	}
+	if _, ok := l.Formatter().(*TextFormatter); !ok {
+		t.Errorf("unexpected formatter: %T", l.Formatter())
+	}
+
+	if err := l.ApplyConfig(&Config{Flags: []string{"unknown"}}); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	saved := configPollInterval
+	configPollInterval = 10 * time.Millisecond
+	defer func() { configPollInterval = saved }()
+
+	path := filepath.Join(t.TempDir(), "ctxlog.json")
+	if err := os.WriteFile(path, []byte(`{"level":"info"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := New(discard, "", 0)
+	defer l.Close()
+	if err := l.WatchConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Level(); got != LevelInfo {
+		t.Errorf("unexpected level: got %v, want %v", got, LevelInfo)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"level":"error"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for l.Level() != LevelError {
+		if time.Now().After(deadline) {
+			t.Fatal("the config is not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchConfig_YAML(t *testing.T) {
+	for _, name := range []string{"ctxlog.yaml", "ctxlog.YML"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte("level: info\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		l := New(discard, "", 0)
+		err := l.WatchConfig(path)
+		l.Close()
+		if err == nil || !strings.Contains(err.Error(), "only JSON is supported") {
+			t.Errorf("%s: want the unsupported format error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ctxlog
+
+import "io"
+
+// ConsoleFormatter formats entries compactly for reading in the console during development:
+//
+//	15:04:05 INF message key=value key2=value2
+//
+// The flags of the logger other than Lshortfile and Llongfile are ignored,
+// and the time is always written in TimeLayout.
+type ConsoleFormatter struct {
+	// EnableColor colors the level with ANSI escape sequences, like TextFormatter.
+	EnableColor bool
+
+	// TimeLayout is the layout of the time. The default is "15:04:05".
+	TimeLayout string
+}
+
+var _ Formatter = (*ConsoleFormatter)(nil)
+
+// NewConsoleFormatter returns a new ConsoleFormatter for out.
+// The level is colored if out is a terminal, unless the NO_COLOR environment variable is set.
+//
+//	logger.SetFormatter(ctxlog.NewConsoleFormatter(os.Stderr))
+func NewConsoleFormatter(out io.Writer) *ConsoleFormatter {
+	return &ConsoleFormatter{EnableColor: colorEnabled(out)}
+}
+
+func levelAbbr(level Level) string {
+	switch level {
+	case LevelDebug:
+		return "DBG"
+	case LevelInfo:
+		return "INF"
+	case LevelWarn:
+		return "WRN"
+	case LevelError:
+		return "ERR"
+	case LevelFatal:
+		return "FTL"
+	case LevelPanic:
+		return "PNC"
+	case LevelNo:
+		return "---"
+	case LevelDisabled:
+		return "DIS"
+	}
+	return "TRC"
+}
+
+// Format implements Formatter.
+func (f *ConsoleFormatter) Format(dst []byte, entry *Entry) ([]byte, error) {
+	e := encodeStatePool.Get().(*encodeState)
+	defer encodeStatePool.Put(e)
+	e.resetEntry(entry)
+
+	layout := f.TimeLayout
+	if layout == "" {
+		layout = "15:04:05"
+	}
+	t := entry.Time
+	if entry.Flags&LUTC != 0 {
+		t = t.UTC()
+	}
+	e.Write(t.AppendFormat(e.scratch[:0], layout))
+	e.WriteByte(' ')
+
+	level := levelAbbr(entry.Level)
+	color := ""
+	if f.EnableColor && entry.Level >= entry.colorLevel {
+		color = levelColor(entry.Level)
+	}
+	if color != "" {
+		e.WriteString(color)
+		e.WriteString(level)
+		e.WriteString(colorReset)
+	} else {
+		e.WriteString(level)
+	}
+
+	if entry.Flags&(Lshortfile|Llongfile) != 0 {
+		e.WriteByte(' ')
+		e.appendTextMessage(entry.File)
+		e.WriteByte(':')
+		e.appendInt(int64(entry.Line))
+	}
+
+	e.WriteByte(' ')
+	e.appendTextMessage(entry.Message)
+	err := e.appendTextFields(entry.Fields)
+	e.WriteByte('\n')
+	return append(dst, e.Bytes()...), err
+}
@@ -0,0 +1,73 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestConsoleFormatter(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC),
+		Level:   LevelWarn,
+		Message: "hello world",
+		Fields: []KV{
+			{Key: "number", Value: 42},
+			{Key: "quoted", Value: "foo bar"},
+		},
+	}
+
+	tests := []struct {
+		f    *ConsoleFormatter
+		want string
+	}{
+		{
+			f:    &ConsoleFormatter{},
+			want: "04:05:06 WRN hello world number=42 quoted=\"foo bar\"\n",
+		},
+		{
+			f:    &ConsoleFormatter{EnableColor: true},
+			want: "04:05:06 \x1b[33mWRN\x1b[0m hello world number=42 quoted=\"foo bar\"\n",
+		},
+		{
+			f:    &ConsoleFormatter{TimeLayout: time.Kitchen},
+			want: "4:05AM WRN hello world number=42 quoted=\"foo bar\"\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.f.Format(nil, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("got %q, want %q", string(got), tt.want)
+		}
+	}
+}
+
+func TestNewConsoleFormatter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lshortfile)
+	l.SetFormatter(NewConsoleFormatter(buf))
+	l.Info(context.Background(), "hello", nil)
+	if got, want := buf.String()[9:], "INF console_test.go:53 hello\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestConsoleFormatter_ControlCharacters(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC),
+		Level:   LevelInfo,
+		Message: "a\n04:05:06 INF \x1b[31mforged",
+	}
+	got, err := (&ConsoleFormatter{}).Format(nil, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "04:05:06 INF \"a\\n04:05:06 INF \\u001b[31mforged\"\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", string(got), want)
+	}
+}
@@ -0,0 +1,98 @@
+package ctxlog
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Loggable is implemented by values that describe themselves as fields.
+// The context values registered by RegisterContextKey that implement Loggable
+// are expanded into fields.
+type Loggable interface {
+	LogFields() Fields
+}
+
+// maxContextKeys is the maximum number of the registered context keys scanned for each entry.
+const maxContextKeys = 32
+
+type contextKey struct {
+	key  any
+	name string
+}
+
+var (
+	contextKeysMu sync.Mutex
+	contextKeys   atomic.Pointer[[]contextKey]
+)
+
+// RegisterContextKey registers key of the context values to be logged.
+// If the value of key in the context implements Loggable, its fields are expanded.
+// Otherwise the value is emitted as the field name, unless name is empty.
+// The fields have lower precedence than the fields attached by With.
+//
+// Only the first 32 registered keys are scanned to bound the cost of logging.
+func RegisterContextKey(key any, name string) {
+	contextKeysMu.Lock()
+	defer contextKeysMu.Unlock()
+
+	var keys []contextKey
+	if old := contextKeys.Load(); old != nil {
+		keys = append(keys, (*old)...)
+	}
+	keys = append(keys, contextKey{key: key, name: name})
+	contextKeys.Store(&keys)
+}
+
+// addContextValues adds the fields from the context values of the registered keys.
+// It costs nothing but an atomic load if no key is registered, which is the common case.
+func (e *encodeState) addContextValues(ctx context.Context) {
+	keys := contextKeys.Load()
+	if keys == nil {
+		return
+	}
+	for i, k := range *keys {
+		if i >= maxContextKeys {
+			break
+		}
+		v := ctx.Value(k.key)
+		if v == nil {
+			continue
+		}
+		if l, ok := v.(Loggable); ok {
+			if fields := l.LogFields(); len(fields) > 0 {
+				e.addFields(fields)
+			}
+		} else if k.name != "" {
+			e.addField(k.name, v)
+		}
+	}
+}
+
+// ContextExtractor returns the function set by SetContextExtractor.
+func (l *Logger) ContextExtractor() func(context.Context) Fields {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.extractor
+}
+
+// SetContextExtractor sets the function that extracts the fields from the context of each entry,
+// e.g. the trace and span IDs propagated in the context.
+// The extracted fields have lower precedence than the context fields and the per-call fields,
+// and higher precedence than the fields bound by With.
+// fn is called for every entry, so it must be cheap and safe for concurrent use.
+// If fn is nil, no field is extracted.
+//
+// The ctxlogotel module provides the extractor for OpenTelemetry:
+//
+//	logger.SetContextExtractor(ctxlogotel.TraceFields)
+func (l *Logger) SetContextExtractor(fn func(context.Context) Fields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.extractor = fn
+}
+
+// SetContextExtractor sets the function that extracts the fields from the context of the standard logger.
+func SetContextExtractor(fn func(context.Context) Fields) {
+	std.SetContextExtractor(fn)
+}
@@ -0,0 +1,111 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type testSession struct {
+	user   string
+	tenant string
+}
+
+func (s *testSession) LogFields() Fields {
+	return Fields{
+		"user":   s.user,
+		"tenant": s.tenant,
+	}
+}
+
+type testContextKey string
+
+const (
+	testSessionKey   testContextKey = "session"
+	testRequestIDKey testContextKey = "request_id"
+	testTraceIDKey   testContextKey = "trace_id" // not registered
+)
+
+func TestRegisterContextKey(t *testing.T) {
+	RegisterContextKey(testSessionKey, "")
+	RegisterContextKey(testRequestIDKey, "request_id")
+
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+
+	ctx := context.WithValue(context.Background(), testSessionKey, &testSession{user: "alice", tenant: "example"})
+	ctx = context.WithValue(ctx, testRequestIDKey, "req-1")
+	ctx = With(ctx, Fields{"tenant": "override"})
+	l.Info(ctx, "hello", nil)
+
+	var got struct {
+		User      string
+		Tenant    string
+		RequestID string `json:"request_id"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.User != "alice" {
+		t.Errorf("unexpected user: got %q, want %q", got.User, "alice")
+	}
+	if got.Tenant != "override" {
+		t.Errorf("unexpected tenant: got %q, want %q", got.Tenant, "override")
+	}
+	if got.RequestID != "req-1" {
+		t.Errorf("unexpected request_id: got %q, want %q", got.RequestID, "req-1")
+	}
+}
+
+func BenchmarkContextKey(b *testing.B) {
+	saved := contextKeys.Load()
+	defer contextKeys.Store(saved)
+
+	ctx := With(context.Background(), Fields{"parent": "hello"})
+	fields := Fields{"string": "foobar"}
+	l := New(discard, "", LstdFlags)
+
+	b.Run("unregistered", func(b *testing.B) {
+		contextKeys.Store(nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info(ctx, "test", fields)
+		}
+	})
+
+	b.Run("registered", func(b *testing.B) {
+		contextKeys.Store(nil)
+		RegisterContextKey(testSessionKey, "")
+		RegisterContextKey(testRequestIDKey, "request_id")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info(ctx, "test", fields)
+		}
+	})
+}
+
+func TestSetContextExtractor(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetContextExtractor(func(ctx context.Context) Fields {
+		id, _ := ctx.Value(testTraceIDKey).(string)
+		if id == "" {
+			return nil
+		}
+		return Fields{"trace_id": id, "span_id": "extracted"}
+	})
+	child := l.With(Fields{"trace_id": "bound", "component": "test"})
+
+	ctx := context.WithValue(context.Background(), testTraceIDKey, "trace-1")
+	ctx = With(ctx, Fields{"span_id": "context"})
+	child.Info(ctx, "hello", nil)
+	child.Info(context.Background(), "no trace", nil)
+
+	want := `{"level":"info","message":"hello","component":"test","span_id":"context","trace_id":"trace-1"}` + "\n" +
+		`{"level":"info","message":"no trace","component":"test","trace_id":"bound"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
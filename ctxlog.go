@@ -2,9 +2,17 @@ package ctxlog
 
 import (
 	"context"
+	"encoding"
+	"flag"
+	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"os"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -65,35 +73,203 @@ func (lv Level) String() string {
 	return "trace"
 }
 
+// ParseLevel parses the name of a level case-insensitively.
+// It also accepts integers, which is useful for the levels less than LevelTrace.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "fatal":
+		return LevelFatal, nil
+	case "panic":
+		return LevelPanic, nil
+	case "no":
+		return LevelNo, nil
+	case "disabled":
+		return LevelDisabled, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return Level(n), nil
+	}
+	return 0, fmt.Errorf("ctxlog: unknown level: %q", s)
+}
+
+var (
+	_ encoding.TextMarshaler   = Level(0)
+	_ encoding.TextUnmarshaler = (*Level)(nil)
+)
+
+// MarshalText implements encoding.TextMarshaler.
+// The levels without names, such as the ones less than LevelTrace, are marshaled as integers,
+// so that they round-trip through UnmarshalText.
+func (lv Level) MarshalText() ([]byte, error) {
+	if lv < LevelTrace || lv > LevelDisabled {
+		return strconv.AppendInt(nil, int64(lv), 10), nil
+	}
+	return []byte(lv.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// It accepts the same forms as ParseLevel.
+func (lv *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lv = level
+	return nil
+}
+
+var _ flag.Value = (*Level)(nil)
+
+// Set implements flag.Value.
+func (lv *Level) Set(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	*lv = level
+	return nil
+}
+
+// Type returns the type name of Level for pflag compatibility.
+func (lv *Level) Type() string {
+	return "level"
+}
+
 type Logger struct {
-	mu        sync.RWMutex // ensures atomic writes; protects the following fields
-	prefix    string       // prefix on each line to identify the logger (but see Lmsgprefix)
-	flag      int          // properties
-	out       io.Writer    // for accumulating text to write
-	isDiscard atomic.Bool  // whether out == io.Discard
-	level     Level
-	pool      sync.Pool
+	mu          sync.RWMutex        // ensures atomic writes; protects the following fields
+	prefix      string              // prefix on each line to identify the logger (but see Lmsgprefix)
+	id          string              // identifier of the logger, emitted as the "instance" field
+	flag        int                 // properties
+	out         io.Writer           // for accumulating text to write
+	isDiscard   atomic.Bool         // whether out and all of levelOut are io.Discard
+	levelOut    map[Level]io.Writer // see SetLevelOutput
+	parent      *Logger             // the logger that With derived the logger from
+	bound       *mergedFields       // the fields bound by With
+	inheritOut  atomic.Bool         // whether out is inherited from parent
+	ownLevel    bool                // whether level overrides the one of parent
+	ownFlag     bool                // whether flag overrides the one of parent
+	ownPrefix   bool                // whether prefix overrides the one of parent
+	routeKey    string              // see SetRoutingField
+	routes      map[string]io.Writer
+	level       Level
+	merge       MergeFunc
+	nilPolicy   NilPolicy
+	maxFields   int
+	formatter   Formatter
+	errHandler  func(error)                   // reports the errors of encoding fields
+	extractor   func(context.Context) Fields  // see SetContextExtractor
+	hooks       []func(Level, map[string]any) // see AddHook
+	processors  []func(*Entry) bool           // see AddProcessor
+	redactKeys  []string                      // see SetRedactKeys
+	name        string                        // see Named
+	nameKey     string                        // see SetNameKey
+	fieldOrder  FieldOrder
+	slogHandler slog.Handler // see NewFromSlogHandler
+	sampler     Sampler
+	defaults    Fields // fields emitted with every entry
+	timeField   string // layout of time.Time values in fields
+	timeFormat  TimeFormatter
+	levelFormat LevelFormat
+	colorLevel  Level      // the minimum level colored by TextFormatter
+	names       FieldNames // keys of the reserved fields
+	eventLevel  Level      // level of the entries logged by Event
+	fatalCode   int        // exit code of FatalContext
+	callerSkip  int        // see SetCallerSkip
+	stackPolicy StackTracePolicy
+	stackLevel  Level    // the minimum level of the entries with stack traces
+	fingerprint []string // keys of the fields included in the fingerprint
+	priority    []string // keys of the fields emitted first
+	auditOut    io.Writer
+	auditFormat Formatter
+	eventOut    io.Writer
+	eventFormat Formatter
+	stopWatch   func()   // stops WatchConfig
+	rootKey     string   // key of the object that the fields are nested under
+	once        sync.Map // call sites that WarnOnce has already logged
+	stats       statsCounter
+	fieldStats  atomic.Pointer[map[string]*fieldCounter] // see SetFieldStatsKeys
 }
 
 var std = New(os.Stderr, "", LstdFlags)
 
+// processStart is the time when the package is initialized, for the Luptime flag.
+// It resets per process.
+var processStart = time.Now()
+
 // Default returns the standard logger used by the package-level output functions.
 func Default() *Logger { return std }
 
 func New(out io.Writer, prefix string, flag int) *Logger {
-	return &Logger{
-		out:    out,
-		prefix: prefix,
-		flag:   flag,
-		pool: sync.Pool{
-			New: func() any {
-				return newEncodeState()
-			},
-		},
+	l := &Logger{
+		out:        out,
+		prefix:     prefix,
+		flag:       flag,
+		eventLevel: LevelInfo,
+		stackLevel: LevelError,
+		fatalCode:  1,
+		colorLevel: minLevel,
 	}
+	l.updateDiscard()
+	return l
+}
+
+// NewDevelopment returns a new Logger for development that writes to os.Stderr.
+// If os.Stderr is a terminal, the logger writes colored human-readable text at debug level.
+// Otherwise, it writes JSON at info level.
+func NewDevelopment() *Logger {
+	l := New(os.Stderr, "", LstdFlags|Lshortfile)
+	if isTerminal(os.Stderr) {
+		l.SetFormatter(NewTextFormatter(os.Stderr))
+		l.SetLevel(LevelDebug)
+	} else {
+		l.SetLevel(LevelInfo)
+	}
+	return l
+}
+
+// NewProduction returns a new Logger for production that writes to w.
+// It is configured for machine ingestion:
+//
+//   - entries are formatted in JSON, with the caller as a nested "caller" object
+//   - the time is in UTC with microsecond resolution
+//   - the level is info
+//   - every entry carries the "hostname" and "pid" fields, and the "commit" and "build_time" fields (see Lbuildinfo)
+//   - debug and info entries are sampled: the first 100 entries with the same level and message in a second are logged,
+//     and then every 100th entry
+func NewProduction(w io.Writer) *Logger {
+	l := New(w, "", LstdFlags|LUTC|Lshortfile|Lbuildinfo)
+	l.SetFormatter(&JSONFormatter{NestedCaller: true})
+	l.SetLevel(LevelInfo)
+	hostname, _ := os.Hostname()
+	l.SetDefaultFields(Fields{
+		"hostname": hostname,
+		"pid":      os.Getpid(),
+	})
+	l.SetSampler(&levelSampler{
+		max: LevelInfo,
+		sampler: &CountSampler{
+			Interval:   time.Second,
+			First:      100,
+			Thereafter: 100,
+		},
+	})
+	return l
 }
 
 func (l *Logger) Writer() io.Writer {
+	if l.inheritOut.Load() {
+		return l.parent.Writer()
+	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	return l.out
@@ -103,26 +279,751 @@ func (l *Logger) SetOutput(w io.Writer) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.out = w
-	l.isDiscard.Store(w == io.Discard)
+	l.inheritOut.Store(false)
+	l.updateDiscard()
+}
+
+// LevelOutput returns the output destination for the entries at level:
+// the writer set by SetLevelOutput if any, otherwise the one set by SetOutput.
+func (l *Logger) LevelOutput(level Level) io.Writer {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if w, ok := l.levelOut[level]; ok {
+		return w
+	}
+	if l.inheritOut.Load() {
+		return l.parent.LevelOutput(level)
+	}
+	return l.out
+}
+
+// SetLevelOutput sets the output destination for the entries at level,
+// which overrides the one set by SetOutput.
+// If w is nil, the override is removed.
+// For example, the following routes warnings and above to os.Stderr,
+// and the others to os.Stdout:
+//
+//	logger.SetOutput(os.Stdout)
+//	for _, level := range []ctxlog.Level{ctxlog.LevelWarn, ctxlog.LevelError, ctxlog.LevelFatal, ctxlog.LevelPanic} {
+//		logger.SetLevelOutput(level, os.Stderr)
+//	}
+func (l *Logger) SetLevelOutput(level Level, w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w == nil {
+		delete(l.levelOut, level)
+	} else {
+		if l.levelOut == nil {
+			l.levelOut = make(map[Level]io.Writer)
+		}
+		l.levelOut[level] = w
+	}
+	l.updateDiscard()
+}
+
+// SetLevelOutput sets the output destination for the entries at level of the standard logger.
+// See Logger.SetLevelOutput for details.
+func SetLevelOutput(level Level, w io.Writer) {
+	std.SetLevelOutput(level, w)
+}
+
+// SetRoutingField routes the entries by the value of the field key:
+// an entry whose field key is a string in routes is written to the corresponding writer,
+// e.g. for isolating the logs of tenants.
+// The other entries are written to the output chosen by SetOutput and SetLevelOutput.
+// If key is empty or routes is empty, the routing is disabled.
+//
+//	logger.SetRoutingField("tenant", map[string]io.Writer{
+//		"a": sinkA,
+//		"b": sinkB,
+//	})
+func (l *Logger) SetRoutingField(key string, routes map[string]io.Writer) {
+	var copied map[string]io.Writer
+	if key != "" && len(routes) > 0 {
+		copied = make(map[string]io.Writer, len(routes))
+		for k, w := range routes {
+			copied[k] = w
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.routeKey = key
+	l.routes = copied
+	if copied == nil {
+		l.routeKey = ""
+	}
+	l.updateDiscard()
+}
+
+// SetRoutingField routes the entries of the standard logger by the value of the field key.
+// See Logger.SetRoutingField for details.
+func SetRoutingField(key string, routes map[string]io.Writer) {
+	std.SetRoutingField(key, routes)
+}
+
+// routeOutput returns the output destination for the entry at level with fields.
+func (l *Logger) routeOutput(level Level, fields []KV) io.Writer {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.routeKey != "" {
+		for _, f := range fields {
+			if f.Key != l.routeKey {
+				continue
+			}
+			if v, ok := f.Value.(string); ok {
+				if w, ok := l.routes[v]; ok {
+					return w
+				}
+			}
+			break
+		}
+	}
+	if w, ok := l.levelOut[level]; ok {
+		return w
+	}
+	if l.inheritOut.Load() {
+		return l.parent.routeOutput(level, fields)
+	}
+	return l.out
+}
+
+// updateDiscard updates isDiscard, so that the logger skips formatting entries
+// only if all the destinations are io.Discard.
+// If out is inherited, the destinations of the parent are checked by discarding.
+// l.mu must be held.
+func (l *Logger) updateDiscard() {
+	discard := l.out == io.Discard || l.inheritOut.Load()
+	for _, w := range l.routedOutputs() {
+		if w != io.Discard {
+			discard = false
+		}
+	}
+	l.isDiscard.Store(discard)
+}
+
+// discarding reports whether all the destinations of the logger are io.Discard.
+func (l *Logger) discarding() bool {
+	if !l.isDiscard.Load() {
+		return false
+	}
+	if l.inheritOut.Load() {
+		return l.parent.discarding()
+	}
+	return true
+}
+
+var _ io.Closer = (*Logger)(nil)
+
+// Close flushes the outputs if they have a Flush method, such as *bufio.Writer,
+// and then closes the outputs if they implement io.Closer,
+// including the ones set by SetLevelOutput, SetRoutingField, SetAuditOutput and SetEventOutput.
+// os.Stdout and os.Stderr are not closed.
+// It also stops watching the configuration file of WatchConfig.
+// The logger must not be used after Close.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stopWatch != nil {
+		l.stopWatch()
+		l.stopWatch = nil
+	}
+
+	err := closeOutput(l.out)
+	closed := map[io.Writer]bool{l.out: true}
+	for _, w := range l.outputs() {
+		if closed[w] {
+			continue
+		}
+		closed[w] = true
+		if cerr := closeOutput(w); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Flush flushes the outputs if they have a Flush method, such as *bufio.Writer and *AsyncWriter,
+// including the ones set by SetLevelOutput, SetRoutingField, SetAuditOutput and SetEventOutput.
+// A logger derived by With flushes the output shared with its parent.
+func (l *Logger) Flush() error {
+	return l.eachWriter(flushOutput)
+}
+
+// Flush flushes the outputs of the standard logger.
+func Flush() error {
+	return std.Flush()
+}
+
+// Sync flushes the outputs like Flush, and then commits them to the storage
+// if they have a Sync method, such as *os.File.
+// It is a no-op for the plain writers, and for os.Stdout and os.Stderr, which are not buffered.
+// The Fatal variants call Sync before exiting, so that the fatal entry is not lost.
+func (l *Logger) Sync() error {
+	return l.eachWriter(syncOutput)
+}
+
+// Sync flushes and syncs the outputs of the standard logger.
+func Sync() error {
+	return std.Sync()
+}
+
+// eachWriter calls fn for each output of l, and the outputs inherited from the parents.
+// The calls are serialized with the writes of the entries.
+func (l *Logger) eachWriter(fn func(io.Writer) error) error {
+	var ws []io.Writer
+	for ll := l; ll != nil; ll = ll.parent {
+		ll.mu.RLock()
+		ws = append(ws, ll.out)
+		ws = append(ws, ll.outputs()...)
+		ll.mu.RUnlock()
+		if !ll.inheritOut.Load() {
+			break
+		}
+	}
+
+	root := l.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	var err error
+	done := make(map[io.Writer]bool, len(ws))
+	for _, w := range ws {
+		if w == nil || done[w] {
+			continue
+		}
+		done[w] = true
+		if ferr := fn(w); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// flushOutput flushes w if it has a Flush method.
+func flushOutput(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// syncOutput flushes w, and then syncs w except os.Stdout and os.Stderr.
+func syncOutput(w io.Writer) error {
+	err := flushOutput(w)
+	if w == os.Stdout || w == os.Stderr {
+		return err
+	}
+	if s, ok := w.(interface{ Sync() error }); ok {
+		if serr := s.Sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// outputs returns the writers set by SetLevelOutput, SetRoutingField, SetAuditOutput and SetEventOutput.
+// The writers may be duplicated.
+// l.mu must be held.
+func (l *Logger) outputs() []io.Writer {
+	ws := l.routedOutputs()
+	if l.auditOut != nil {
+		ws = append(ws, l.auditOut)
+	}
+	if l.eventOut != nil {
+		ws = append(ws, l.eventOut)
+	}
+	return ws
+}
+
+// routedOutputs returns the writers set by SetLevelOutput and SetRoutingField,
+// which the entries other than the audit entries and the events are written to.
+// l.mu must be held.
+func (l *Logger) routedOutputs() []io.Writer {
+	ws := make([]io.Writer, 0, len(l.levelOut)+len(l.routes)+2)
+	for _, w := range l.levelOut {
+		ws = append(ws, w)
+	}
+	for _, w := range l.routes {
+		ws = append(ws, w)
+	}
+	return ws
+}
+
+// closeOutput flushes and closes w except os.Stdout and os.Stderr.
+func closeOutput(w io.Writer) error {
+	var err error
+	if f, ok := w.(interface{ Flush() error }); ok {
+		err = f.Flush()
+	}
+	if w == os.Stdout || w == os.Stderr {
+		return err
+	}
+	if c, ok := w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
 func (l *Logger) SetLevel(level Level) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.level = level
+	l.ownLevel = true
 }
 
 func (l *Logger) Level() Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.parent != nil && !l.ownLevel {
+		return l.parent.Level()
+	}
+	return l.level
+}
+
+// Enabled reports whether the logger writes the entries at level:
+// level is not filtered out by SetLevel, and the output is not io.Discard.
+// It is cheap, so that callers can guard the expensive construction of the fields:
+//
+//	if logger.Enabled(ctxlog.LevelDebug) {
+//		logger.Debug(ctx, "state", ctxlog.Fields{"dump": dump(state)})
+//	}
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level() && !l.discarding()
+}
+
+// Enabled reports whether the standard logger writes the entries at level.
+func Enabled(level Level) bool {
+	return std.Enabled(level)
+}
+
+// EnabledContext is like Enabled, but it also asks the slog.Handler of the logger
+// created by NewFromSlogHandler, which may decide by ctx.
+//
+//	if logger.EnabledContext(ctx, ctxlog.LevelDebug) {
+//		logger.Debug(ctx, "state", ctxlog.Fields{"dump": dump(state)})
+//	}
+func (l *Logger) EnabledContext(ctx context.Context, level Level) bool {
+	if !l.Enabled(level) {
+		return false
+	}
+	if h := l.SlogHandler(); h != nil {
+		return h.Enabled(ctx, levelToSlog(level))
+	}
+	return true
+}
+
+// EnabledContext reports whether the standard logger writes the entries at level with ctx.
+func EnabledContext(ctx context.Context, level Level) bool {
+	return std.EnabledContext(ctx, level)
+}
+
+// Formatter returns the formatter of the logger.
+func (l *Logger) Formatter() Formatter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.formatter == nil {
+		return defaultFormatter
+	}
+	return l.formatter
+}
+
+// SetFormatter sets the formatter of the logger.
+// If it is nil, the logger uses JSONFormatter, which is the default.
+func (l *Logger) SetFormatter(f Formatter) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return l.level
+	l.formatter = f
+}
+
+// SetFormatter sets the formatter of the standard logger.
+// If it is nil, the logger uses JSONFormatter, which is the default.
+func SetFormatter(f Formatter) {
+	std.SetFormatter(f)
+}
+
+// ErrorHandler returns the function that reports the errors of encoding fields and writing entries.
+func (l *Logger) ErrorHandler() func(error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.errHandler
+}
+
+// SetErrorHandler sets the function that reports the errors of encoding fields and writing entries,
+// such as a full disk or a broken pipe to a log shipper,
+// which are otherwise lost because the level helpers such as Info ignore them.
+// A field that fails to encode doesn't drop the entry:
+// its value is replaced with "!ENCODE_ERROR", the rest of the entry is written,
+// and then fn is called with the error.
+// fn is called without holding any lock of the logger, so it may log, e.g. to another logger.
+// If it is nil, which is the default, the errors are only returned from OutputContext.
+func (l *Logger) SetErrorHandler(fn func(error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errHandler = fn
+}
+
+// SetErrorHandler sets the function that reports the errors of encoding fields and writing entries of the standard logger.
+func SetErrorHandler(fn func(error)) {
+	std.SetErrorHandler(fn)
+}
+
+// LevelFormat controls how the level of entries is rendered.
+type LevelFormat int
+
+const (
+	// LevelFull renders the level by Level.String, such as "info".
+	// It is the default.
+	LevelFull LevelFormat = iota
+
+	// LevelShort renders the level as a single letter, such as "I".
+	LevelShort
+
+	// LevelUpper renders the level in upper case, such as "INFO".
+	LevelUpper
+)
+
+// format returns the representation of level in f.
+func (f LevelFormat) format(level Level) string {
+	switch f {
+	case LevelShort:
+		return levelShort(level)
+	case LevelUpper:
+		return levelUpper(level)
+	}
+	return level.String()
+}
+
+func levelShort(level Level) string {
+	switch level {
+	case LevelDebug:
+		return "D"
+	case LevelInfo:
+		return "I"
+	case LevelWarn:
+		return "W"
+	case LevelError:
+		return "E"
+	case LevelFatal:
+		return "F"
+	case LevelPanic:
+		return "P"
+	case LevelNo:
+		return "N"
+	case LevelDisabled:
+		return "X"
+	}
+	return "T"
+}
+
+// LevelFormat returns the format of the level field.
+func (l *Logger) LevelFormat() LevelFormat {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.levelFormat
+}
+
+// SetLevelFormat sets the format of the level field, such as LevelShort.
+// The single-letter levels reduce the size of logs with many tiny messages.
+func (l *Logger) SetLevelFormat(f LevelFormat) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levelFormat = f
+}
+
+// minLevel is the most verbose level, which colors all the levels by default.
+const minLevel = Level(math.MinInt)
+
+// ColorLevels returns the minimum level of the entries colored by TextFormatter.
+func (l *Logger) ColorLevels() Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.colorLevel
+}
+
+// SetColorLevels colors only the entries at min or above,
+// when the formatter is TextFormatter with EnableColor.
+// The entries below min are written in plain text,
+// e.g. SetColorLevels(LevelWarn) highlights only the problems.
+func (l *Logger) SetColorLevels(min Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.colorLevel = min
+}
+
+// SetColorLevels colors only the entries at min or above of the standard logger.
+func SetColorLevels(min Level) {
+	std.SetColorLevels(min)
+}
+
+// RootKey returns the key of the object that the fields are nested under.
+func (l *Logger) RootKey() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.rootKey
+}
+
+// SetRootKey sets the key of the object that the fields are nested under,
+// for the ingestion systems that reserve the top level for their own metadata.
+// For example, with SetRootKey("app"), JSONFormatter writes:
+//
+//	{"time":"2001-02-03T04:05:06Z","level":"info","message":"hello","app":{"key":"value"}}
+//
+// The reserved fields such as "time" and "level" stay at the top level.
+// If it is empty, which is the default, the fields are written at the top level.
+func (l *Logger) SetRootKey(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rootKey = key
+}
+
+// The layouts for SetTimeFieldLayout with various sub-second precision.
+const (
+	TimeFieldSeconds = "2006-01-02T15:04:05Z07:00"
+	TimeFieldMillis  = "2006-01-02T15:04:05.000Z07:00"
+	TimeFieldMicros  = "2006-01-02T15:04:05.000000Z07:00"
+	TimeFieldNanos   = "2006-01-02T15:04:05.000000000Z07:00"
+)
+
+// TimeFieldLayout returns the layout of time.Time values in fields.
+func (l *Logger) TimeFieldLayout() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.timeField
+}
+
+// SetTimeFieldLayout sets the layout of time.Time values in fields, such as TimeFieldMillis.
+// It is independent of the precision of the "time" field of the entry.
+// If it is empty, which is the default, time.Time values are encoded by encoding/json.
+func (l *Logger) SetTimeFieldLayout(layout string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timeField = layout
+}
+
+// TimeFormatter appends the JSON encoding of t to dst and returns the extended buffer.
+// It may encode t as a string or as a number.
+type TimeFormatter func(dst []byte, t time.Time) []byte
+
+// TimeRFC3339Nano is a TimeFormatter that encodes t as a string in RFC 3339 with nanoseconds.
+func TimeRFC3339Nano(dst []byte, t time.Time) []byte {
+	dst = append(dst, '"')
+	dst = t.AppendFormat(dst, time.RFC3339Nano)
+	return append(dst, '"')
+}
+
+// TimeUnixMillis is a TimeFormatter that encodes t as a number of the milliseconds since the Unix epoch.
+func TimeUnixMillis(dst []byte, t time.Time) []byte {
+	return strconv.AppendInt(dst, t.UnixMilli(), 10)
+}
+
+// TimeFormatter returns the formatter of the "time" field.
+func (l *Logger) TimeFormatter() TimeFormatter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.timeFormat
+}
+
+// SetTimeFormatter sets the formatter of the "time" field written by JSONFormatter,
+// such as TimeRFC3339Nano and TimeUnixMillis.
+// It overrides the format controlled by Ldate, Ltime and Lmicroseconds,
+// but the field is still written only if any of them is set.
+// If LUTC is set, f receives the time in UTC.
+// If it is nil, which is the default, the format is controlled by the flags.
+func (l *Logger) SetTimeFormatter(f TimeFormatter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timeFormat = f
+}
+
+// SetTimeFormatter sets the formatter of the "time" field of the standard logger.
+func SetTimeFormatter(f TimeFormatter) {
+	std.SetTimeFormatter(f)
+}
+
+// DefaultFields returns the fields emitted with every entry.
+// The returned map must not be modified.
+func (l *Logger) DefaultFields() Fields {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.defaults
+}
+
+// SetDefaultFields sets the fields emitted with every entry, e.g. the name and the version of the service.
+// They have the lowest precedence: the context fields and the per-call fields override them.
+// The keys that conflict with the reserved fields are prefixed with "field.", like the other fields.
+// fields is copied, so it is safe to modify it after the call,
+// and SetDefaultFields is safe to call while the other goroutines are logging.
+func (l *Logger) SetDefaultFields(fields Fields) {
+	defaults := make(Fields, len(fields))
+	for k, v := range fields {
+		defaults[k] = v
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaults = defaults
+}
+
+// SetDefaultFields sets the fields emitted with every entry of the standard logger.
+func SetDefaultFields(fields Fields) {
+	std.SetDefaultFields(fields)
+}
+
+// ID returns the identifier of the logger.
+func (l *Logger) ID() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.id
+}
+
+// SetID sets the identifier of the logger.
+// If it is not empty, every entry carries it as the "instance" field
+// so that entries from loggers sharing the same output can be told apart.
+func (l *Logger) SetID(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.id = id
+}
+
+// osExit is os.Exit, which is replaced in tests.
+var osExit = os.Exit
+
+// exit syncs the outputs so that the fatal entry is not lost in the buffers, and exits with code.
+func (l *Logger) exit(code int) {
+	_ = l.Sync()
+	osExit(code)
+}
+
+// FatalExitCode returns the exit code of the fatal level output functions, such as FatalContext.
+func (l *Logger) FatalExitCode() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.fatalCode
+}
+
+// SetFatalExitCode sets the exit code of the fatal level output functions, such as FatalContext.
+// The default is 1.
+func (l *Logger) SetFatalExitCode(code int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fatalCode = code
+}
+
+// BaseFields returns a copy of the fields that the logger itself adds to every entry:
+// the default fields, the "instance" field of the ID and the fields bound by With.
+// It is the logger-side counterpart of the context fields,
+// and helps to verify the configuration of the logger.
+func (l *Logger) BaseFields() Fields {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	fields := make(Fields, len(l.defaults)+1)
+	for k, v := range l.defaults {
+		fields[k] = v
+	}
+	if l.id != "" {
+		fields["instance"] = l.id
+	}
+
+	// the fields bound by With override the others, and the inner ones win.
+	var layers []Fields
+	for b := l.bound; b != nil; b = b.parent {
+		layers = append(layers, b.fields)
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		for k, v := range layers[i] {
+			fields[k] = v
+		}
+	}
+	return fields
 }
 
 type Fields map[string]any
 
+// MergeFunc combines the values of a field that appears in more than one layer,
+// such as the context and the per-call fields.
+// old is the value from the outer layer, and new is the value from the inner layer.
+type MergeFunc func(key string, old, new any) any
+
+// NilPolicy controls how the fields with nil values are handled.
+type NilPolicy int
+
+const (
+	// NilEmit emits the fields with nil values as null.
+	NilEmit NilPolicy = iota
+
+	// NilOmit drops the fields with nil values, including typed nils such as a nil pointer.
+	NilOmit
+)
+
+// NilPolicy returns the policy for the fields with nil values.
+func (l *Logger) NilPolicy() NilPolicy {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.nilPolicy
+}
+
+// SetNilPolicy sets the policy for the fields with nil values.
+// The default is NilEmit.
+func (l *Logger) SetNilPolicy(policy NilPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nilPolicy = policy
+}
+
+func (l *Logger) normalizeOptions() normalizeOptions {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return normalizeOptions{
+		merge:     l.merge,
+		omitNil:   l.nilPolicy == NilOmit,
+		maxFields: l.maxFields,
+		names:     l.names,
+		insertion: l.fieldOrder == FieldOrderInsertion,
+	}
+}
+
+// MaxFields returns the maximum number of the fields per entry.
+func (l *Logger) MaxFields() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.maxFields
+}
+
+// SetMaxFields sets the maximum number of the fields per entry.
+// If an entry has more fields, the fields with the lowest precedence, such as the outermost context fields, are dropped
+// and the number of the dropped fields is emitted as the "fields_truncated" field.
+// If n is zero, which is the default, the number of the fields is unlimited.
+// The audit entries logged by Audit are not truncated.
+func (l *Logger) SetMaxFields(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxFields = n
+}
+
+// MergeFunc returns the function that combines the values of conflicting fields.
+func (l *Logger) MergeFunc() MergeFunc {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.merge
+}
+
+// SetMergeFunc sets the function that combines the values of conflicting fields.
+// If it is nil, which is the default, the value from the inner layer wins.
+func (l *Logger) SetMergeFunc(merge MergeFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.merge = merge
+}
+
 type mergedFields struct {
 	parent *mergedFields
 	fields Fields
+	until  time.Time // the fields are omitted after until, if it is not zero
 }
 
 type ctxKey struct {
@@ -138,6 +1039,41 @@ func With(parent context.Context, fields Fields) context.Context {
 	})
 }
 
+// WithField returns a copy of parent that carries the field key.
+// It is a shorthand for With with a single field.
+func WithField(parent context.Context, key string, value any) context.Context {
+	return With(parent, Fields{key: value})
+}
+
+// FieldsFromContext returns the fields attached to ctx by With and its variants.
+// The values attached later override the earlier ones, and the expired fields are omitted.
+// The returned map is a copy, so modifying it doesn't affect ctx.
+func FieldsFromContext(ctx context.Context) Fields {
+	fields := Fields{}
+	now := time.Now()
+	for f := contextFields(ctx); f != nil; f = f.parent {
+		if !f.until.IsZero() && now.After(f.until) {
+			continue
+		}
+		for k, v := range f.fields {
+			if _, ok := fields[k]; !ok {
+				fields[k] = v
+			}
+		}
+	}
+	return fields
+}
+
+// WithExpiring returns a copy of parent that carries the field key.
+// The field is omitted from the entries logged after until.
+func WithExpiring(parent context.Context, key string, value any, until time.Time) context.Context {
+	return context.WithValue(parent, keyFields, &mergedFields{
+		parent: contextFields(parent),
+		fields: Fields{key: value},
+		until:  until,
+	})
+}
+
 func contextFields(ctx context.Context) *mergedFields {
 	f := ctx.Value(keyFields)
 	if f == nil {
@@ -146,55 +1082,306 @@ func contextFields(ctx context.Context) *mergedFields {
 	return f.(*mergedFields)
 }
 
+var keyLogger = &ctxKey{"ctxlog-logger"}
+
+// NewContext returns a copy of parent that carries l,
+// e.g. for a middleware that installs a request-scoped logger:
+//
+//	logger := base.With(ctxlog.Fields{"component": "api"})
+//	ctx = ctxlog.NewContext(ctx, logger)
+//	...
+//	ctxlog.FromContext(ctx).Info(ctx, "hello", nil)
+//
+// The logger doesn't replace the fields attached by With:
+// the entries carry both the fields bound to the logger and the fields of the context.
+func NewContext(parent context.Context, l *Logger) context.Context {
+	return context.WithValue(parent, keyLogger, l)
+}
+
+// FromContext returns the logger carried by ctx.
+// If ctx has no logger, it returns the standard logger.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(keyLogger).(*Logger); ok && l != nil {
+		return l
+	}
+	return std
+}
+
+var keyTimezone = &ctxKey{"ctxlog-timezone"}
+
+// WithTimezone returns a copy of parent that formats the time of entries in loc,
+// regardless of the LUTC flag of the logger.
+func WithTimezone(parent context.Context, loc *time.Location) context.Context {
+	return context.WithValue(parent, keyTimezone, loc)
+}
+
+func contextTimezone(ctx context.Context) *time.Location {
+	loc, _ := ctx.Value(keyTimezone).(*time.Location)
+	return loc
+}
+
+var keyNoCaller = &ctxKey{"ctxlog-nocaller"}
+
+// WithoutCaller returns a copy of parent that suppresses the caller lookup,
+// even if the logger has the Lshortfile, Llongfile or Lfuncname flag.
+// It saves the cost of runtime.Caller for the high-frequency entries on hot paths:
+//
+//	ctxlog.Info(ctxlog.WithoutCaller(ctx), "cache hit", nil)
+func WithoutCaller(parent context.Context) context.Context {
+	return context.WithValue(parent, keyNoCaller, true)
+}
+
+func callerDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(keyNoCaller).(bool)
+	return disabled
+}
+
+var keyCallerPC = &ctxKey{"ctxlog-caller-pc"}
+
+// withCallerPC returns a copy of parent that carries the program counter of the caller,
+// for the adapters that know the caller better than runtime.Caller, such as the slog handler.
+func withCallerPC(parent context.Context, pc uintptr) context.Context {
+	return context.WithValue(parent, keyCallerPC, pc)
+}
+
+// contextCaller returns the location and the function name of the caller set by withCallerPC.
+func contextCaller(ctx context.Context) (file string, line int, function string, ok bool) {
+	pc, _ := ctx.Value(keyCallerPC).(uintptr)
+	if pc == 0 {
+		return "", 0, "", false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return frame.File, frame.Line, frame.Function, frame.File != ""
+}
+
+var keyRecordTime = &ctxKey{"ctxlog-record-time"}
+
+// withRecordTime returns a copy of parent that carries the time of the entry,
+// for the adapters that record the time before the entry is logged, such as the slog handler.
+func withRecordTime(parent context.Context, t time.Time) context.Context {
+	return context.WithValue(parent, keyRecordTime, t)
+}
+
+// contextRecordTime returns the time of the entry set by withRecordTime.
+func contextRecordTime(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(keyRecordTime).(time.Time)
+	return t, ok
+}
+
+var keyBudget = &ctxKey{"ctxlog-budget"}
+
+// WithBudget returns a copy of parent that records the total timeout budget of the call chain.
+// If the Lbudget flag is set, the entries logged with a context that has a deadline
+// carry the remaining budget as the "budget_pct" field,
+// in percent of total.
+func WithBudget(parent context.Context, total time.Duration) context.Context {
+	return context.WithValue(parent, keyBudget, total)
+}
+
+// budgetPercent returns the remaining timeout budget at now in percent.
+func budgetPercent(ctx context.Context, now time.Time) (float64, bool) {
+	total, ok := ctx.Value(keyBudget).(time.Duration)
+	if !ok || total <= 0 {
+		return 0, false
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	remaining := deadline.Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	pct := float64(remaining) / float64(total) * 100
+	return math.Round(pct*10) / 10, true
+}
+
+// doneReason returns why ctx is done.
+// It returns an empty string if ctx is not done.
+func doneReason(ctx context.Context) string {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return "timeout"
+	case context.Canceled:
+		return "canceled"
+	}
+	return ""
+}
+
+type mergedTags struct {
+	parent *mergedTags
+	tags   []string
+}
+
+var keyTags = &ctxKey{"ctxlog-tags"}
+
+// WithTags returns a copy of parent that carries tags.
+// Tags accumulate across context layers as a set,
+// and they are emitted as the "tags" field in sorted order.
+func WithTags(parent context.Context, tags ...string) context.Context {
+	return context.WithValue(parent, keyTags, &mergedTags{
+		parent: contextTags(parent),
+		tags:   append([]string(nil), tags...),
+	})
+}
+
+func contextTags(ctx context.Context) *mergedTags {
+	t := ctx.Value(keyTags)
+	if t == nil {
+		return nil
+	}
+	return t.(*mergedTags)
+}
+
+// appendTags appends the de-duplicated and sorted tags of t to dst.
+func appendTags(dst []string, t *mergedTags) []string {
+	for t != nil {
+		dst = append(dst, t.tags...)
+		t = t.parent
+	}
+	if len(dst) == 0 {
+		return dst
+	}
+	sort.Strings(dst)
+	n := 1
+	for _, tag := range dst[1:] {
+		if tag != dst[n-1] {
+			dst[n] = tag
+			n++
+		}
+	}
+	return dst[:n]
+}
+
+// CallerSkip returns the number of the extra stack frames skipped to find the caller.
+func (l *Logger) CallerSkip() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.callerSkip
+}
+
+// SetCallerSkip sets the number of the extra stack frames skipped to find the caller,
+// for the Lshortfile, Llongfile and Lfuncname flags, the stack traces and WarnOnce.
+// The libraries that wrap the logger set it to the number of their frames,
+// so that the file and the line point to the callers of the wrappers:
+//
+//	// mylog.Info calls logger.Info.
+//	logger.SetCallerSkip(1)
+func (l *Logger) SetCallerSkip(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.callerSkip = n
+}
+
+// SetCallerSkip sets the number of the extra stack frames skipped to find the caller of the standard logger.
+func SetCallerSkip(n int) {
+	std.SetCallerSkip(n)
+}
+
 // Output writes the output for a logging event.
 func (l *Logger) OutputContext(ctx context.Context, calldepth int, level Level, msg string, fields Fields) error {
-	if level < l.Level() {
+	return l.output(ctx, calldepth+1, level, msg, nil, fields)
+}
+
+// output is the implementation of OutputContext.
+// extra is emitted in the order with the highest precedence.
+func (l *Logger) output(ctx context.Context, calldepth int, level Level, msg string, extra []KV, fields Fields) error {
+	ok, sampled := l.enabled(level, msg)
+	if !ok {
 		return nil
 	}
+	if sampled > 0 {
+		extra = appendSampled(extra, sampled)
+	}
+	return l.emit(ctx, calldepth+1, level, msg, extra, fields, nil, l.Formatter(), false)
+}
 
-	now := time.Now() // get this early.
+// enabled reports whether the entry passes the level filtering and the sampling,
+// and the number of the entries dropped by the sampler before it.
+func (l *Logger) enabled(level Level, msg string) (bool, int) {
+	if level < l.Level() {
+		return false, 0
+	}
+	if s := l.Sampler(); s != nil {
+		return sample(s, level, msg)
+	}
+	return true, 0
+}
 
-	state := l.pool.Get().(*encodeState)
-	defer l.pool.Put(state)
-	state.Reset()
+// appendSampled appends the "sampled" field to extra without modifying the backing array of extra.
+func appendSampled(extra []KV, sampled int) []KV {
+	return append(extra[:len(extra):len(extra)], KV{Key: "sampled", Value: sampled})
+}
+
+// emit formats the entry by formatter and writes it to out, without filtering.
+// If out is nil, the output is chosen by the level and the routing field of the entry.
+// If audit is true, the entry is neither dropped by the processors nor truncated by SetMaxFields.
+func (l *Logger) emit(ctx context.Context, calldepth int, level Level, msg string, extra []KV, fields Fields, out io.Writer, formatter Formatter, audit bool) error {
+	now := time.Now() // get this early.
+	if t, ok := contextRecordTime(ctx); ok {
+		now = t
+	}
+	calldepth += l.CallerSkip()
 
-	state.WriteByte('{')
+	state := encodeStatePool.Get().(*encodeState)
+	defer encodeStatePool.Put(state)
 
 	flags := l.Flags()
-	if flags&(Ldate|Ltime|Lmicroseconds) != 0 {
-		state.appendString("time")
-		state.WriteByte(':')
-		state.WriteByte('"')
-		state.appendTime(flags, now)
-		state.WriteByte('"')
-		state.WriteByte(',')
-	}
-
-	state.appendString("level")
-	state.WriteByte(':')
-	state.appendString(level.String())
-	state.WriteByte(',')
-
-	state.appendString("message")
-	state.WriteByte(':')
-	state.WriteByte('"')
-	if l.Flags()&Lmsgprefix == 0 {
-		state.appendRawString(l.Prefix())
-		state.appendRawString(msg)
-	} else {
-		state.appendRawString(msg)
-		state.appendRawString(l.Prefix())
+	entry := &state.entry
+	*entry = Entry{
+		Time:        now,
+		Level:       level,
+		Message:     msg,
+		Flags:       flags,
+		timeLayout:  l.TimeFieldLayout(),
+		timeFormat:  l.TimeFormatter(),
+		levelFormat: l.LevelFormat(),
+		colorLevel:  l.ColorLevels(),
+		names:       l.FieldNames(),
+		rootKey:     l.RootKey(),
+	}
+	defer func() {
+		*entry = Entry{} // for Garbage Collection
+	}()
+
+	if loc := contextTimezone(ctx); loc != nil {
+		entry.Time = now.In(loc)
+		entry.Flags &^= LUTC
+	}
+
+	if prefix := l.Prefix(); prefix != "" {
+		if flags&Lmsgprefix == 0 {
+			entry.Message = prefix + msg
+		} else {
+			entry.Message = msg + prefix
+		}
+	}
+
+	if flags&(Lshortfile|Llongfile|Lfuncname) != 0 && callerDisabled(ctx) {
+		entry.Flags &^= Lshortfile | Llongfile | Lfuncname
 	}
-	state.WriteByte('"')
 
 	// stack trace
-	if l.Flags()&(Lshortfile|Llongfile) != 0 {
-		_, file, line, ok := runtime.Caller(calldepth)
+	var function string
+	if entry.Flags&(Lshortfile|Llongfile|Lfuncname) != 0 {
+		file, line, fn, ok := contextCaller(ctx)
+		if !ok {
+			var pc uintptr
+			pc, file, line, ok = runtime.Caller(calldepth)
+			if ok && entry.Flags&Lfuncname != 0 {
+				if f := runtime.FuncForPC(pc); f != nil {
+					fn = f.Name()
+				}
+			}
+		}
+		if entry.Flags&Lfuncname != 0 {
+			function = fn
+		}
 		if !ok {
 			file = "???"
 			line = 0
 		} else {
-			if l.flag&Lshortfile != 0 {
+			if flags&Lshortfile != 0 {
 				short := file
 				for i := len(file) - 1; i > 0; i-- {
 					if file[i] == '/' {
@@ -205,33 +1392,154 @@ func (l *Logger) OutputContext(ctx context.Context, calldepth int, level Level,
 				file = short
 			}
 		}
+		if entry.Flags&(Lshortfile|Llongfile) != 0 {
+			entry.File = file
+			entry.Line = line
+		}
+	}
 
-		state.WriteByte(',')
-		state.appendString("file")
-		state.WriteByte(':')
-		state.appendString(file)
-		state.WriteByte(',')
-		state.appendString("line")
-		state.WriteByte(':')
-		state.appendInt(int64(line))
+	state.resetFields()
+	defer state.clearFields()
+	for _, f := range extra {
+		state.addField(f.Key, f.Value)
+	}
+	state.addFields(fields)
+	state.addMergedFields(contextFields(ctx), now)
+	state.addContextValues(ctx)
+	if fn := l.ContextExtractor(); fn != nil {
+		state.addFields(fn(ctx))
+	}
+	state.addMergedFields(l.bound, now)
+	if name := l.Name(); name != "" {
+		state.addField(l.NameKey(), name)
+	}
+	state.addMergedFields(currentGoroutineFields(), now)
+	state.tags = appendTags(state.tags[:0], contextTags(ctx))
+	if len(state.tags) > 0 {
+		state.addField("tags", state.tags)
+	}
+	if id := l.ID(); id != "" {
+		state.addField("instance", id)
+	}
+	if flags&Lunixmilli != 0 {
+		state.addField("ts_unix", now.UnixMilli())
+	}
+	if flags&Luptime != 0 {
+		state.addField("uptime_ms", now.Sub(processStart).Milliseconds())
+	}
+	if procIDSupported && flags&Lprocid != 0 {
+		state.addField("procid", procID())
+	}
+	if flags&Lbudget != 0 {
+		if pct, ok := budgetPercent(ctx, now); ok {
+			state.addField("budget_pct", pct)
+		}
+	}
+	if flags&Ldonereason != 0 {
+		if reason := doneReason(ctx); reason != "" {
+			state.addField("done_reason", reason)
+		}
+	}
+	if function != "" {
+		state.addField("func", function)
+	}
+	if flags&Lbuildinfo != 0 {
+		commit, buildTime := readBuildInfo()
+		if commit != "" {
+			state.addField("commit", commit)
+		}
+		if buildTime != "" {
+			state.addField("build_time", buildTime)
+		}
+	}
+	state.addFields(l.DefaultFields())
+	opts := l.normalizeOptions()
+	if audit {
+		opts.maxFields = 0
+	}
+	entry.Fields = state.normalizeFields(&opts)
+	if hooks := l.Hooks(); len(hooks) > 0 {
+		runHooks(hooks, entry, opts.insertion)
+		level = entry.Level
+	}
+	if processors := l.Processors(); len(processors) > 0 {
+		if !runProcessors(processors, entry, audit) {
+			return nil
+		}
+		level = entry.Level
+	}
+	if keys := l.RedactKeys(); len(keys) > 0 {
+		redactFields(entry.Fields, keys)
+	}
+	if n := maskPII(entry); n > 0 {
+		l.stats.masked.Add(uint64(n))
+	}
+	if flags&Lerrorstack != 0 {
+		entry.Fields = state.insertErrorStacks(entry.Fields)
+	}
+	if flags&Lfingerprint != 0 {
+		state.timeLayout = entry.timeLayout
+		state.utc = flags&LUTC != 0
+		fingerprint := state.fingerprint(entry.Message, entry.Fields, l.FingerprintFields())
+		entry.Fields = state.insertField(entry.Fields, "fingerprint", fingerprint)
+	}
+	if level >= l.StackTraceLevel() {
+		if policy := l.StackTracePolicy(); policy != StackTraceOff {
+			if stack, ok := stackTrace(policy, entry.Fields, calldepth); ok {
+				entry.Fields = state.insertField(entry.Fields, "stack", stack)
+			}
+		}
+	}
+	l.addFieldStats(entry.Fields)
+	if keys := schema.Load(); keys != nil {
+		entry.Fields = orderFields(entry.Fields, *keys)
+	}
+	if priority := l.PriorityFields(); len(priority) > 0 {
+		entry.Fields = orderFields(entry.Fields, priority)
 	}
 
-	if err := state.appendFields(contextFields(ctx), fields); err != nil {
-		return err
+	if out == nil {
+		if h := l.SlogHandler(); h != nil {
+			return handleSlog(ctx, h, entry, calldepth)
+		}
+		out = l.routeOutput(level, entry.Fields)
+	}
+	if m, ok := out.(*multiOutput); ok {
+		return l.writeOutputs(m, state, entry)
+	}
+
+	var encErr error
+	state.line, encErr = formatter.Format(state.line[:0], entry)
+	if encErr != nil {
+		l.handleError(encErr)
+		if len(state.line) == 0 {
+			return encErr
+		}
 	}
 
-	state.WriteByte('}')
-	state.WriteByte('\n')
+	root := l.root()
+	root.mu.Lock()
+	n, err := writeLevel(out, level, state.line)
+	root.mu.Unlock()
+	l.stats.add(level, int64(n))
+	if err != nil {
+		// the handler is called without the lock, so that it can log.
+		l.handleError(err)
+		return err
+	}
+	return encErr
+}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	_, err := state.WriteTo(l.out)
-	return err
+// handleError reports err to the error handler if any.
+func (l *Logger) handleError(err error) {
+	if fn := l.ErrorHandler(); fn != nil {
+		fn(err)
+	}
 }
 
 // Trace writes the output for a trace level logging event.
 func (l *Logger) Trace(ctx context.Context, msg string, fields Fields) {
-	if l.isDiscard.Load() {
+	if !l.Enabled(LevelTrace) {
 		return
 	}
 	l.OutputContext(ctx, 2, LevelTrace, msg, fields)
@@ -239,7 +1547,7 @@ func (l *Logger) Trace(ctx context.Context, msg string, fields Fields) {
 
 // Debug writes the output for a debug level logging event.
 func (l *Logger) Debug(ctx context.Context, msg string, fields Fields) {
-	if l.isDiscard.Load() {
+	if !l.Enabled(LevelDebug) {
 		return
 	}
 	l.OutputContext(ctx, 2, LevelDebug, msg, fields)
@@ -247,7 +1555,7 @@ func (l *Logger) Debug(ctx context.Context, msg string, fields Fields) {
 
 // Info writes the output for an info level logging event.
 func (l *Logger) Info(ctx context.Context, msg string, fields Fields) {
-	if l.isDiscard.Load() {
+	if !l.Enabled(LevelInfo) {
 		return
 	}
 	l.OutputContext(ctx, 2, LevelInfo, msg, fields)
@@ -255,24 +1563,67 @@ func (l *Logger) Info(ctx context.Context, msg string, fields Fields) {
 
 // Warn writes the output for a warn level logging event.
 func (l *Logger) Warn(ctx context.Context, msg string, fields Fields) {
-	if l.isDiscard.Load() {
+	if !l.Enabled(LevelWarn) {
 		return
 	}
 	l.OutputContext(ctx, 2, LevelWarn, msg, fields)
 }
 
+// WarnOnce writes the output for a warn level logging event,
+// but only the first time it is called from the call site.
+func (l *Logger) WarnOnce(ctx context.Context, msg string, fields Fields) {
+	if !l.Enabled(LevelWarn) {
+		return
+	}
+	l.warnOnce(ctx, 3, msg, fields)
+}
+
+type callSite struct {
+	file string
+	line int
+}
+
+func (l *Logger) warnOnce(ctx context.Context, calldepth int, msg string, fields Fields) {
+	_, file, line, ok := runtime.Caller(calldepth - 1 + l.CallerSkip())
+	if !ok {
+		return
+	}
+	if _, loaded := l.once.LoadOrStore(callSite{file: file, line: line}, struct{}{}); loaded {
+		return
+	}
+	l.OutputContext(ctx, calldepth, LevelWarn, msg, fields)
+}
+
+// ResetOnce forgets the call sites that WarnOnce has already logged.
+// It is intended for tests.
+func (l *Logger) ResetOnce() {
+	l.once.Range(func(key, value any) bool {
+		l.once.Delete(key)
+		return true
+	})
+}
+
 // Error writes the output for an error level logging event.
 func (l *Logger) Error(ctx context.Context, msg string, fields Fields) {
-	if l.isDiscard.Load() {
+	if !l.Enabled(LevelError) {
 		return
 	}
 	l.OutputContext(ctx, 2, LevelError, msg, fields)
 }
 
-// FatalContext writes the output for a fatal level logging event.
+// FatalContext writes the output for a fatal level logging event,
+// and exits with the fatal exit code, see SetFatalExitCode.
 func (l *Logger) FatalContext(ctx context.Context, msg string, fields Fields) {
 	l.OutputContext(ctx, 2, LevelFatal, msg, fields)
-	os.Exit(1)
+	l.exit(l.FatalExitCode())
+}
+
+// FatalCode writes the output for a fatal level logging event, and exits with code.
+// It allows the process supervisors to distinguish the fatal conditions,
+// e.g. configuration errors (78) from generic failures (1).
+func (l *Logger) FatalCode(ctx context.Context, code int, msg string, fields Fields) {
+	l.OutputContext(ctx, 2, LevelFatal, msg, fields)
+	l.exit(code)
 }
 
 // PanicContext writes the output for an panic level logging event.
@@ -283,7 +1634,7 @@ func (l *Logger) PanicContext(ctx context.Context, msg string, fields Fields) {
 
 // Trace writes the output for a trace level logging event.
 func Trace(ctx context.Context, msg string, fields Fields) {
-	if std.isDiscard.Load() {
+	if !std.Enabled(LevelTrace) {
 		return
 	}
 	std.OutputContext(ctx, 2, LevelTrace, msg, fields)
@@ -291,7 +1642,7 @@ func Trace(ctx context.Context, msg string, fields Fields) {
 
 // Debug writes the output for a debug level logging event.
 func Debug(ctx context.Context, msg string, fields Fields) {
-	if std.isDiscard.Load() {
+	if !std.Enabled(LevelDebug) {
 		return
 	}
 	std.OutputContext(ctx, 2, LevelDebug, msg, fields)
@@ -299,7 +1650,7 @@ func Debug(ctx context.Context, msg string, fields Fields) {
 
 // Info writes the output for an info level logging event.
 func Info(ctx context.Context, msg string, fields Fields) {
-	if std.isDiscard.Load() {
+	if !std.Enabled(LevelInfo) {
 		return
 	}
 	std.OutputContext(ctx, 2, LevelInfo, msg, fields)
@@ -307,24 +1658,46 @@ func Info(ctx context.Context, msg string, fields Fields) {
 
 // Warn writes the output for a warn level logging event.
 func Warn(ctx context.Context, msg string, fields Fields) {
-	if std.isDiscard.Load() {
+	if !std.Enabled(LevelWarn) {
 		return
 	}
 	std.OutputContext(ctx, 2, LevelWarn, msg, fields)
 }
 
+// WarnOnce writes the output for a warn level logging event,
+// but only the first time it is called from the call site.
+func WarnOnce(ctx context.Context, msg string, fields Fields) {
+	if !std.Enabled(LevelWarn) {
+		return
+	}
+	std.warnOnce(ctx, 3, msg, fields)
+}
+
 // Error writes the output for an error level logging event.
 func Error(ctx context.Context, msg string, fields Fields) {
-	if std.isDiscard.Load() {
+	if !std.Enabled(LevelError) {
 		return
 	}
 	std.OutputContext(ctx, 2, LevelError, msg, fields)
 }
 
-// FatalContext writes the output for a fatal level logging event.
+// FatalContext writes the output for a fatal level logging event,
+// and exits with the fatal exit code, see SetFatalExitCode.
 func FatalContext(ctx context.Context, msg string, fields Fields) {
 	std.OutputContext(ctx, 2, LevelFatal, msg, fields)
-	os.Exit(1)
+	std.exit(std.FatalExitCode())
+}
+
+// SetFatalExitCode sets the exit code of the fatal level output functions of the standard logger.
+// The default is 1.
+func SetFatalExitCode(code int) {
+	std.SetFatalExitCode(code)
+}
+
+// FatalCode writes the output for a fatal level logging event, and exits with code.
+func FatalCode(ctx context.Context, code int, msg string, fields Fields) {
+	std.OutputContext(ctx, 2, LevelFatal, msg, fields)
+	std.exit(code)
 }
 
 // PanicContext writes the output for an panic level logging event.
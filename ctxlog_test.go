@@ -4,6 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -29,6 +36,21 @@ func TestFormatTime(t *testing.T) {
 			now:  time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC),
 			want: "2001-02-03T04:05:06.123456Z",
 		},
+		{
+			flag: Ldate | Ltime,
+			now:  time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.FixedZone("Asia/Tokyo", 9*60*60)),
+			want: "2001-02-03T04:05:06+09:00",
+		},
+		{
+			flag: Ldate | Ltime,
+			now:  time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.FixedZone("America/St_Johns", -(3*60+30)*60)),
+			want: "2001-02-03T04:05:06-03:30",
+		},
+		{
+			flag: Ldate | Ltime,
+			now:  time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC),
+			want: "2001-02-03T04:05:06Z",
+		},
 	}
 
 	for i, tt := range tests {
@@ -90,6 +112,27 @@ func TestOutputFields(t *testing.T) {
 	}
 }
 
+func TestWithTags(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+
+	ctx := WithTags(context.Background(), "foo", "bar")
+	ctx = WithTags(ctx, "baz", "foo")
+	l.Info(ctx, "hoge", nil)
+
+	var got struct {
+		Tags []string
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"bar", "baz", "foo"}
+	if !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("got %v, want %v", got.Tags, want)
+	}
+}
+
 func TestStackTrace(t *testing.T) {
 	buf := new(bytes.Buffer)
 	l := New(buf, "", Lshortfile)
@@ -114,6 +157,33 @@ func TestStackTrace(t *testing.T) {
 	}
 }
 
+// testWrapper is a wrapper library of the logger, which adds a stack frame.
+func testWrapper(l *Logger, msg string) {
+	l.Info(context.Background(), msg, nil)
+}
+
+func TestSetCallerSkip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lshortfile)
+	l.SetCallerSkip(1)
+	_, _, line, _ := runtime.Caller(0)
+	testWrapper(l, "hello")
+
+	var got struct {
+		File string
+		Line int
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.File != "ctxlog_test.go" {
+		t.Errorf("unexpected file name: got %q, want \"ctxlog_test.go\"", got.File)
+	}
+	if got.Line != line+1 {
+		t.Errorf("unexpected line number: got %d, want %d", got.Line, line+1)
+	}
+}
+
 type blackhole struct{}
 
 // discard is same as io.Discard, but it avoids optimization to io.Discard.
@@ -171,11 +241,90 @@ func BenchmarkOutputFlag(b *testing.B) {
 
 	const testString = "test"
 	l := New(discard, "", LstdFlags)
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		l.Info(ctx, testString, fields)
 	}
 }
 
+func BenchmarkFormatter(b *testing.B) {
+	ctx := With(context.Background(), Fields{"parent": "hello"})
+	fields := Fields{
+		"string":  "foobar",
+		"number":  42,
+		"boolean": true,
+	}
+	formatters := []struct {
+		name string
+		f    Formatter
+	}{
+		{"json", &JSONFormatter{}},
+		{"text", &TextFormatter{}},
+		{"console", &ConsoleFormatter{}},
+	}
+	for _, tt := range formatters {
+		b.Run(tt.name, func(b *testing.B) {
+			l := New(discard, "", LstdFlags)
+			l.SetFormatter(tt.f)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Info(ctx, "test", fields)
+			}
+		})
+	}
+}
+
+func TestOutputAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops the items randomly under the race detector")
+	}
+	ctx := With(context.Background(), Fields{"parent": "hello"})
+	fields := Fields{
+		"string":  "foobar",
+		"number":  42,
+		"boolean": true,
+	}
+	// runtime.Caller allocates, so Lshortfile is not set.
+	l := New(discard, "", LstdFlags)
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Info(ctx, "test", fields)
+	})
+	if allocs != 0 {
+		t.Errorf("unexpected allocations: got %f, want 0", allocs)
+	}
+}
+
+func TestFilteredAllocs(t *testing.T) {
+	ctx := With(context.Background(), Fields{"parent": "hello"})
+	fields := Fields{"string": "foobar"}
+	l := New(discard, "", LstdFlags)
+	l.SetLevel(LevelInfo)
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Debug(ctx, "test", fields)
+	})
+	if allocs != 0 {
+		t.Errorf("unexpected allocations: got %f, want 0", allocs)
+	}
+	if l.Enabled(LevelDebug) {
+		t.Error("want debug level disabled, got enabled")
+	}
+	if !l.Enabled(LevelInfo) {
+		t.Error("want info level enabled, got disabled")
+	}
+}
+
+func BenchmarkFiltered(b *testing.B) {
+	ctx := With(context.Background(), Fields{"parent": "hello"})
+	fields := Fields{"string": "foobar"}
+	l := New(discard, "", LstdFlags)
+	l.SetLevel(LevelInfo)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Debug(ctx, "test", fields)
+	}
+}
+
 func BenchmarkOutputFlagParallel(b *testing.B) {
 	parent := map[string]any{
 		"parent": "hello",
@@ -189,9 +338,759 @@ func BenchmarkOutputFlagParallel(b *testing.B) {
 
 	const testString = "test"
 	l := New(discard, "", LstdFlags)
+	b.ReportAllocs()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			l.Info(ctx, testString, fields)
 		}
 	})
 }
+
+func TestWithoutCaller(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lshortfile)
+	l.Info(WithoutCaller(context.Background()), "hello", nil)
+	want := `{"level":"info","message":"hello"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFuncname(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lfuncname)
+	l.Info(context.Background(), "hello", nil)
+	l.Info(WithoutCaller(context.Background()), "no caller", nil)
+	func() {
+		l.Info(context.Background(), "closure", nil)
+	}()
+
+	want := `{"level":"info","message":"hello","func":"github.com/shogo82148/ctxlog.TestFuncname"}` + "\n" +
+		`{"level":"info","message":"no caller"}` + "\n" +
+		`{"level":"info","message":"closure","func":"github.com/shogo82148/ctxlog.TestFuncname.func1"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestWarnOnce(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lshortfile)
+
+	for i := 0; i < 3; i++ {
+		l.WarnOnce(context.Background(), "deprecated", nil)
+	}
+	l.WarnOnce(context.Background(), "another call site", nil)
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 2 {
+		t.Errorf("unexpected number of lines: got %d, want 2", got)
+	}
+
+	var got struct {
+		File string
+	}
+	line, _, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+	if err := json.Unmarshal(line, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.File != "ctxlog_test.go" {
+		t.Errorf("unexpected file name: got %q, want \"ctxlog_test.go\"", got.File)
+	}
+
+	buf.Reset()
+	l.ResetOnce()
+	for i := 0; i < 3; i++ {
+		l.WarnOnce(context.Background(), "deprecated", nil)
+	}
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 1 {
+		t.Errorf("unexpected number of lines: got %d, want 1", got)
+	}
+}
+
+func TestWithExpiring(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+
+	ctx := WithExpiring(context.Background(), "alive", "foo", time.Now().Add(time.Hour))
+	ctx = WithExpiring(ctx, "expired", "bar", time.Now().Add(-time.Hour))
+	l.Info(ctx, "hoge", nil)
+
+	var got map[string]any
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["alive"] != "foo" {
+		t.Errorf("unexpected alive field: got %v, want %q", got["alive"], "foo")
+	}
+	if _, ok := got["expired"]; ok {
+		t.Errorf("expired field is emitted: %v", got["expired"])
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Default() {
+		t.Errorf("want the standard logger, got %p", got)
+	}
+
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0).With(Fields{"component": "api"})
+	ctx := NewContext(context.Background(), l)
+	ctx = With(ctx, Fields{"request_id": "req-1"})
+	if got := FromContext(ctx); got != l {
+		t.Errorf("want the logger in the context, got %p", got)
+	}
+
+	FromContext(ctx).Info(ctx, "hello", nil)
+	want := `{"level":"info","message":"hello","component":"api","request_id":"req-1"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
+
+func TestFieldsFromContext(t *testing.T) {
+	ctx := With(context.Background(), Fields{"user": "alice", "tenant": "example"})
+	ctx = WithField(ctx, "user", "bob")
+	ctx = WithExpiring(ctx, "expired", "bar", time.Now().Add(-time.Hour))
+
+	got := FieldsFromContext(ctx)
+	want := Fields{"user": "bob", "tenant": "example"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected fields: got %v, want %v", got, want)
+	}
+
+	// the returned map is a copy.
+	got["user"] = "mallory"
+	if got := FieldsFromContext(ctx); got["user"] != "bob" {
+		t.Errorf("the context is modified: got %v, want %q", got["user"], "bob")
+	}
+
+	if got := FieldsFromContext(context.Background()); len(got) != 0 {
+		t.Errorf("unexpected fields: got %v, want empty", got)
+	}
+}
+
+func TestSetID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetID("audit")
+	l.Info(context.Background(), "hoge", nil)
+
+	var got struct {
+		Instance string
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Instance != "audit" {
+		t.Errorf("unexpected instance: got %q, want %q", got.Instance, "audit")
+	}
+}
+
+func TestBaseFields(t *testing.T) {
+	l := New(discard, "", 0)
+	l.SetDefaultFields(Fields{"hostname": "example", "instance": "overridden"})
+	l.SetID("api-1")
+
+	got := l.BaseFields()
+	want := Fields{"hostname": "example", "instance": "api-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSetDefaultFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	defaults := Fields{"service": "checkout", "version": "1.4.2", "level": "default"}
+	l.SetDefaultFields(defaults)
+	defaults["service"] = "modified"
+
+	ctx := With(context.Background(), Fields{"version": "context"})
+	l.Info(ctx, "hello", Fields{"service": "per-call"})
+	l.Info(context.Background(), "hello", nil)
+
+	want := `{"level":"info","message":"hello","field.level":"default","service":"per-call","version":"context"}` + "\n" +
+		`{"level":"info","message":"hello","field.level":"default","service":"checkout","version":"1.4.2"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func BenchmarkDefaultFields(b *testing.B) {
+	ctx := With(context.Background(), Fields{"parent": "hello"})
+	fields := Fields{"string": "foobar"}
+	l := New(discard, "", LstdFlags)
+	l.SetDefaultFields(Fields{"service": "checkout", "version": "1.4.2"})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info(ctx, "test", fields)
+	}
+}
+
+func TestSetFatalExitCode(t *testing.T) {
+	var code int
+	osExit = func(c int) { code = c }
+	defer func() { osExit = os.Exit }()
+
+	l := New(discard, "", 0)
+	l.FatalContext(context.Background(), "fatal", nil)
+	if code != 1 {
+		t.Errorf("unexpected exit code: got %d, want 1", code)
+	}
+
+	l.SetFatalExitCode(2)
+	l.FatalContext(context.Background(), "fatal", nil)
+	if code != 2 {
+		t.Errorf("unexpected exit code: got %d, want 2", code)
+	}
+
+	l.FatalCode(context.Background(), 78, "config error", nil)
+	if code != 78 {
+		t.Errorf("unexpected exit code: got %d, want 78", code)
+	}
+}
+
+func TestSetMergeFunc(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetMergeFunc(func(key string, old, new any) any {
+		if key != "resources" {
+			return new
+		}
+		return old.(string) + "," + new.(string)
+	})
+
+	ctx := With(context.Background(), Fields{"resources": "a", "user": "alice"})
+	ctx = With(ctx, Fields{"resources": "b", "user": "bob"})
+	l.Info(ctx, "hoge", Fields{"resources": "c"})
+
+	var got struct {
+		Resources string
+		User      string
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Resources != "a,b,c" {
+		t.Errorf("unexpected resources: got %q, want %q", got.Resources, "a,b,c")
+	}
+	if got.User != "bob" {
+		t.Errorf("unexpected user: got %q, want %q", got.User, "bob")
+	}
+}
+
+func TestNewProduction(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewProduction(buf)
+	l.Debug(context.Background(), "filtered", nil)
+	l.Info(context.Background(), "hello", Fields{"caller": "user"})
+
+	var got struct {
+		Time   string
+		Level  string
+		Caller struct {
+			File string
+			Line int
+		}
+		FieldCaller string `json:"field.caller"`
+		Hostname    string
+		PID         int
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Level != "info" {
+		t.Errorf("unexpected level: got %q, want %q", got.Level, "info")
+	}
+	if !strings.HasSuffix(got.Time, "Z") {
+		t.Errorf("unexpected time: %q", got.Time)
+	}
+	if got.Caller.File != "ctxlog_test.go" {
+		t.Errorf("unexpected file name: got %q, want \"ctxlog_test.go\"", got.Caller.File)
+	}
+	if got.FieldCaller != "user" {
+		t.Errorf("unexpected field.caller: got %q, want %q", got.FieldCaller, "user")
+	}
+	if got.PID != os.Getpid() {
+		t.Errorf("unexpected pid: got %d, want %d", got.PID, os.Getpid())
+	}
+}
+
+func TestUnixMilli(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", LstdFlags|LUTC|Lunixmilli)
+	before := time.Now().UnixMilli()
+	l.Info(context.Background(), "hello", nil)
+	after := time.Now().UnixMilli()
+
+	var got struct {
+		Time   string
+		TSUnix int64 `json:"ts_unix"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Time == "" {
+		t.Error("time field is missing")
+	}
+	if got.TSUnix < before || got.TSUnix > after {
+		t.Errorf("unexpected ts_unix: got %d, want between %d and %d", got.TSUnix, before, after)
+	}
+}
+
+func TestUptime(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Luptime)
+	before := time.Since(processStart).Milliseconds()
+	l.Info(context.Background(), "hello", nil)
+	after := time.Since(processStart).Milliseconds()
+
+	var got struct {
+		UptimeMS *int64 `json:"uptime_ms"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.UptimeMS == nil {
+		t.Fatal("uptime_ms field is missing")
+	}
+	if *got.UptimeMS < before || *got.UptimeMS > after {
+		t.Errorf("unexpected uptime_ms: got %d, want between %d and %d", *got.UptimeMS, before, after)
+	}
+}
+
+func TestSetNilPolicy(t *testing.T) {
+	var nilPtr *int
+	fields := Fields{
+		"nil":     nil,
+		"typed":   nilPtr,
+		"string":  "",
+		"integer": 0,
+	}
+
+	tests := []struct {
+		policy NilPolicy
+		want   []string
+	}{
+		{
+			policy: NilEmit,
+			want:   []string{"integer", "level", "message", "nil", "string", "typed"},
+		},
+		{
+			policy: NilOmit,
+			want:   []string{"integer", "level", "message", "string"},
+		},
+	}
+
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		l := New(buf, "", 0)
+		l.SetNilPolicy(tt.policy)
+		l.Info(context.Background(), "hello", fields)
+
+		var got map[string]any
+		t.Log(buf.String())
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		keys := make([]string, 0, len(got))
+		for k := range got {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, tt.want) {
+			t.Errorf("policy %d: got %v, want %v", tt.policy, keys, tt.want)
+		}
+	}
+}
+
+func TestWithBudget(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lbudget)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	ctx = WithBudget(ctx, 2*time.Hour)
+	l.Info(ctx, "hello", nil)
+
+	var got struct {
+		BudgetPct float64 `json:"budget_pct"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.BudgetPct < 49 || got.BudgetPct > 50 {
+		t.Errorf("unexpected budget_pct: got %f, want 50", got.BudgetPct)
+	}
+}
+
+func TestDoneReason(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	timeout, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	tests := []struct {
+		ctx  context.Context
+		want string
+	}{
+		{context.Background(), `{"level":"info","message":"hello"}` + "\n"},
+		{canceled, `{"level":"info","message":"hello","done_reason":"canceled"}` + "\n"},
+		{timeout, `{"level":"info","message":"hello","done_reason":"timeout"}` + "\n"},
+	}
+	for i, tt := range tests {
+		buf := new(bytes.Buffer)
+		l := New(buf, "", Ldonereason)
+		l.Info(tt.ctx, "hello", nil)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%d: got %q, want %q", i, got, tt.want)
+		}
+	}
+}
+
+func TestSetTimeFieldLayout(t *testing.T) {
+	born := time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC)
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{
+			layout: TimeFieldSeconds,
+			want:   "2001-02-03T04:05:06Z",
+		},
+		{
+			layout: TimeFieldMillis,
+			want:   "2001-02-03T04:05:06.123Z",
+		},
+		{
+			layout: TimeFieldMicros,
+			want:   "2001-02-03T04:05:06.123456Z",
+		},
+		{
+			layout: TimeFieldNanos,
+			want:   "2001-02-03T04:05:06.123456789Z",
+		},
+		{
+			layout: `"2006-01-02"`,
+			want:   `"2001-02-03"`,
+		},
+	}
+
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		l := New(buf, "", LstdFlags)
+		l.SetTimeFieldLayout(tt.layout)
+		l.Info(context.Background(), "hello", Fields{"born": born})
+
+		var got struct {
+			Born string
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Born != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.layout, got.Born, tt.want)
+		}
+	}
+}
+
+func TestWithTimezone(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", LstdFlags|LUTC)
+
+	loc := time.FixedZone("Asia/Tokyo", 9*60*60)
+	ctx := WithTimezone(context.Background(), loc)
+	l.Info(ctx, "hello", nil)
+
+	var got struct {
+		Time string
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(got.Time, "+09:00") {
+		t.Errorf("unexpected time: %q", got.Time)
+	}
+}
+
+func TestSetMaxFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetMaxFields(3)
+
+	ctx := With(context.Background(), Fields{"outer1": 1, "outer2": 2})
+	ctx = With(ctx, Fields{"inner": 3, "call": "overridden"})
+	l.Info(ctx, "hello", Fields{"call": 4})
+
+	var got map[string]any
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"level":            "info",
+		"message":          "hello",
+		"call":             float64(4),
+		"inner":            float64(3),
+		"outer1":           float64(1),
+		"fields_truncated": float64(1),
+	}
+	if _, ok := got["outer1"]; !ok {
+		// the order of the fields in the same layer is not stable.
+		delete(want, "outer1")
+		want["outer2"] = float64(2)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+type closeRecorder struct {
+	bytes.Buffer
+	calls []string
+}
+
+func (w *closeRecorder) Flush() error {
+	w.calls = append(w.calls, "flush")
+	return nil
+}
+
+func (w *closeRecorder) Close() error {
+	w.calls = append(w.calls, "close")
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	w := &closeRecorder{}
+	l := New(w, "", 0)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(w.calls, []string{"flush", "close"}) {
+		t.Errorf("unexpected calls: got %v, want [flush close]", w.calls)
+	}
+
+	// os.Stderr is not closed.
+	l = New(os.Stderr, "", 0)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stderr.Stat(); err != nil {
+		t.Errorf("os.Stderr is closed: %v", err)
+	}
+}
+
+type syncRecorder struct {
+	closeRecorder
+}
+
+func (w *syncRecorder) Sync() error {
+	w.calls = append(w.calls, "sync")
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	w := &syncRecorder{}
+	l := New(w, "", 0)
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(w.calls, []string{"flush", "sync"}) {
+		t.Errorf("unexpected calls: got %v, want [flush sync]", w.calls)
+	}
+
+	// plain writers are not synced.
+	l = New(new(bytes.Buffer), "", 0)
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSync_AuditAndEvents(t *testing.T) {
+	out := &syncRecorder{}
+	audit := &syncRecorder{}
+	events := &syncRecorder{}
+	l := New(out, "", 0)
+	l.SetAuditOutput(audit)
+	l.SetEventOutput(events)
+	l.SetLevelOutput(LevelError, events) // the same writer is flushed once.
+
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"flush", "flush", "sync", "flush", "close"}
+	for name, w := range map[string]*syncRecorder{"out": out, "audit": audit, "events": events} {
+		if !reflect.DeepEqual(w.calls, want) {
+			t.Errorf("unexpected calls of %s: got %v, want %v", name, w.calls, want)
+		}
+	}
+}
+
+func TestSync_Fatal(t *testing.T) {
+	osExit = func(c int) {}
+	defer func() { osExit = os.Exit }()
+
+	w := &syncRecorder{}
+	l := New(w, "", 0)
+	l.Fatal("fatal")
+	l.With(Fields{"component": "test"}).Fatalf("fatal %d", 2)
+	want := []string{"flush", "sync", "flush", "sync"}
+	if !reflect.DeepEqual(w.calls, want) {
+		t.Errorf("unexpected calls: got %v, want %v", w.calls, want)
+	}
+	if got := strings.Count(w.String(), "\n"); got != 2 {
+		t.Errorf("unexpected lines: got %d, want 2", got)
+	}
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	var handled []error
+	l.SetErrorHandler(func(err error) {
+		handled = append(handled, err)
+	})
+
+	err := l.OutputContext(context.Background(), 1, LevelInfo, "hello", Fields{
+		"ratio": math.NaN(),
+		"user":  "alice",
+	})
+	if err == nil {
+		t.Error("want an error, got nil")
+	}
+	if len(handled) != 1 || !strings.Contains(handled[0].Error(), `"ratio"`) {
+		t.Errorf("unexpected handled errors: %v", handled)
+	}
+
+	var got struct {
+		Message string `json:"message"`
+		Ratio   string `json:"ratio"`
+		User    string `json:"user"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Message != "hello" || got.Ratio != "!ENCODE_ERROR" || got.User != "alice" {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+
+	// the text formatter writes the placeholder as well.
+	buf.Reset()
+	l.SetFormatter(&TextFormatter{})
+	l.Info(context.Background(), "hello", Fields{"ratio": math.Inf(1)})
+	if !strings.Contains(buf.String(), "ratio=!ENCODE_ERROR") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestSetErrorHandler_Write(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fallback := New(buf, "", 0)
+	l := New(errWriter{}, "", 0)
+	l.SetErrorHandler(func(err error) {
+		// the handler is called without the lock.
+		l.SetPrefix("failed: ")
+		fallback.Error(context.Background(), "failed to write", Fields{"error": err})
+	})
+
+	l.Info(context.Background(), "hello", nil)
+	want := `{"level":"error","message":"failed to write","error":"write error"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+	if got := l.Prefix(); got != "failed: " {
+		t.Errorf("unexpected prefix: got %q, want %q", got, "failed: ")
+	}
+}
+
+func TestSetLevelOutput(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	l := New(stdout, "", 0)
+	l.SetLevelOutput(LevelWarn, stderr)
+	l.SetLevelOutput(LevelError, stderr)
+
+	ctx := context.Background()
+	l.Info(ctx, "info", nil)
+	l.Warn(ctx, "warn", nil)
+	l.Error(ctx, "error", nil)
+	if got, want := stdout.String(), `{"level":"info","message":"info"}`+"\n"; got != want {
+		t.Errorf("unexpected stdout: got %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), `{"level":"warn","message":"warn"}`+"\n"+`{"level":"error","message":"error"}`+"\n"; got != want {
+		t.Errorf("unexpected stderr: got %q, want %q", got, want)
+	}
+
+	// the logger doesn't skip the entries if any destination is not io.Discard.
+	stderr.Reset()
+	l.SetOutput(io.Discard)
+	if l.isDiscard.Load() {
+		t.Error("want isDiscard false, got true")
+	}
+	l.Error(ctx, "error", nil)
+	if stderr.Len() == 0 {
+		t.Error("the error entry is discarded")
+	}
+
+	// nil removes the override.
+	l.SetLevelOutput(LevelWarn, nil)
+	l.SetLevelOutput(LevelError, nil)
+	if !l.isDiscard.Load() {
+		t.Error("want isDiscard true, got false")
+	}
+}
+
+func TestSetLevelOutput_Race(t *testing.T) {
+	l := New(io.Discard, "", 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			l.SetLevelOutput(LevelError, new(bytes.Buffer))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		l.Error(context.Background(), "error", nil)
+	}
+	<-done
+}
+
+func TestSetRoutingField(t *testing.T) {
+	def := new(bytes.Buffer)
+	sinkA := new(bytes.Buffer)
+	sinkB := new(bytes.Buffer)
+	l := New(def, "", 0)
+	l.SetRoutingField("tenant", map[string]io.Writer{
+		"a": sinkA,
+		"b": sinkB,
+	})
+
+	l.Info(With(context.Background(), Fields{"tenant": "a"}), "hello", nil)
+	l.Info(context.Background(), "hello", Fields{"tenant": "b"})
+	l.Info(context.Background(), "hello", Fields{"tenant": "c"})
+	l.Info(context.Background(), "hello", nil)
+
+	if got, want := sinkA.String(), `{"level":"info","message":"hello","tenant":"a"}`+"\n"; got != want {
+		t.Errorf("unexpected sink a: got %q, want %q", got, want)
+	}
+	if got, want := sinkB.String(), `{"level":"info","message":"hello","tenant":"b"}`+"\n"; got != want {
+		t.Errorf("unexpected sink b: got %q, want %q", got, want)
+	}
+	if got, want := def.String(), `{"level":"info","message":"hello","tenant":"c"}`+"\n"+`{"level":"info","message":"hello"}`+"\n"; got != want {
+		t.Errorf("unexpected default output: got %q, want %q", got, want)
+	}
+}
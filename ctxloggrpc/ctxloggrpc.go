@@ -0,0 +1,44 @@
+// Package ctxloggrpc provides helpers for logging gRPC calls.
+// It is a separate module, so that the core package doesn't depend on gRPC.
+package ctxloggrpc
+
+import (
+	"github.com/shogo82148/ctxlog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusFields returns the "grpc_code" and "grpc_message" fields of the gRPC status of err.
+// A nil error is reported as OK, and an error without a status as Unknown.
+//
+//	logger.OutputContext(ctx, 1, ctxloggrpc.StatusLevel(err), "finished call", ctxloggrpc.StatusFields(err))
+func StatusFields(err error) ctxlog.Fields {
+	s, _ := status.FromError(err)
+	fields := ctxlog.Fields{
+		"grpc_code": s.Code().String(),
+	}
+	if msg := s.Message(); msg != "" {
+		fields["grpc_message"] = msg
+	}
+	return fields
+}
+
+// StatusLevel returns the suggested level for logging the gRPC status of err.
+// See CodeLevel for the mapping.
+func StatusLevel(err error) ctxlog.Level {
+	return CodeLevel(status.Code(err))
+}
+
+// CodeLevel returns the suggested level for logging a call finished with code.
+// OK is info, the codes caused by the client or the environment, such as InvalidArgument, are warn,
+// and the codes of server bugs, such as Internal, are error.
+func CodeLevel(code codes.Code) ctxlog.Level {
+	switch code {
+	case codes.OK:
+		return ctxlog.LevelInfo
+	case codes.Unknown, codes.Unimplemented, codes.Internal, codes.DataLoss:
+		return ctxlog.LevelError
+	default:
+		return ctxlog.LevelWarn
+	}
+}
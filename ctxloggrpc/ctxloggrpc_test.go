@@ -0,0 +1,49 @@
+package ctxloggrpc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/shogo82148/ctxlog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusFields(t *testing.T) {
+	tests := []struct {
+		err   error
+		want  ctxlog.Fields
+		level ctxlog.Level
+	}{
+		{
+			err:   nil,
+			want:  ctxlog.Fields{"grpc_code": "OK"},
+			level: ctxlog.LevelInfo,
+		},
+		{
+			err:   status.Error(codes.InvalidArgument, "bad request"),
+			want:  ctxlog.Fields{"grpc_code": "InvalidArgument", "grpc_message": "bad request"},
+			level: ctxlog.LevelWarn,
+		},
+		{
+			err:   status.Error(codes.Internal, "oops"),
+			want:  ctxlog.Fields{"grpc_code": "Internal", "grpc_message": "oops"},
+			level: ctxlog.LevelError,
+		},
+		{
+			err:   errors.New("plain error"),
+			want:  ctxlog.Fields{"grpc_code": "Unknown", "grpc_message": "plain error"},
+			level: ctxlog.LevelError,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := StatusFields(tt.err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("StatusFields(%v): got %v, want %v", tt.err, got, tt.want)
+		}
+		if got := StatusLevel(tt.err); got != tt.level {
+			t.Errorf("StatusLevel(%v): got %v, want %v", tt.err, got, tt.level)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/shogo82148/ctxlog/ctxloggrpc
+
+go 1.21
+
+require (
+	github.com/shogo82148/ctxlog v0.0.0
+	google.golang.org/grpc v1.65.0
+)
+
+require (
+	golang.org/x/sys v0.20.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
+	google.golang.org/protobuf v1.34.1 // indirect
+)
+
+// the parent module is developed together with this module.
+replace github.com/shogo82148/ctxlog => ../
@@ -0,0 +1,28 @@
+// Package ctxlogotel provides the context extractor for OpenTelemetry.
+// It is a separate module, so that the core package doesn't depend on OpenTelemetry.
+package ctxlogotel
+
+import (
+	"context"
+
+	"github.com/shogo82148/ctxlog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ func(context.Context) ctxlog.Fields = TraceFields
+
+// TraceFields returns the "trace_id" and "span_id" fields of the span in ctx.
+// It returns nil if ctx has no valid span context.
+// It is intended to be used with SetContextExtractor:
+//
+//	logger.SetContextExtractor(ctxlogotel.TraceFields)
+func TraceFields(ctx context.Context) ctxlog.Fields {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return ctxlog.Fields{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	}
+}
@@ -0,0 +1,34 @@
+package ctxlogotel
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/shogo82148/ctxlog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceFields(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	buf := new(bytes.Buffer)
+	l := ctxlog.New(buf, "", 0)
+	l.SetContextExtractor(TraceFields)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	l.Info(ctx, "traced", nil)
+	l.Info(context.Background(), "untraced", nil)
+
+	want := `{"level":"info","message":"traced","span_id":"00f067aa0ba902b7","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}` + "\n" +
+		`{"level":"info","message":"untraced"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
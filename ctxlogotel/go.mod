@@ -0,0 +1,13 @@
+module github.com/shogo82148/ctxlog/ctxlogotel
+
+go 1.21
+
+require (
+	github.com/shogo82148/ctxlog v0.0.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require go.opentelemetry.io/otel v1.28.0 // indirect
+
+// the parent module is developed together with this module.
+replace github.com/shogo82148/ctxlog => ../
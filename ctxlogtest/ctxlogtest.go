@@ -0,0 +1,49 @@
+// Package ctxlogtest provides loggers for tests.
+package ctxlogtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shogo82148/ctxlog"
+)
+
+// FailOnError returns a new logger that writes the entries to t.Log,
+// and fails the test by t.Errorf for every entry at error level or above.
+// It surfaces the unexpected error logs that would be swallowed by the loggers writing to io.Discard.
+func FailOnError(t testing.TB) *ctxlog.Logger {
+	return FailOnLevel(t, ctxlog.LevelError)
+}
+
+// FailOnLevel is like FailOnError, but it fails the test for every entry at level or above.
+func FailOnLevel(t testing.TB, level ctxlog.Level) *ctxlog.Logger {
+	w := &writer{
+		t:     t,
+		level: level,
+	}
+	return ctxlog.New(w, "", ctxlog.Lshortfile)
+}
+
+type writer struct {
+	t     testing.TB
+	level ctxlog.Level
+}
+
+var _ ctxlog.LevelWriter = (*writer)(nil)
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.t.Helper()
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+func (w *writer) WriteLevel(level ctxlog.Level, p []byte) (int, error) {
+	w.t.Helper()
+	line := strings.TrimSuffix(string(p), "\n")
+	if level >= w.level {
+		w.t.Errorf("unexpected %s log: %s", level, line)
+	} else {
+		w.t.Log(line)
+	}
+	return len(p), nil
+}
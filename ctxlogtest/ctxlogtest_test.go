@@ -0,0 +1,52 @@
+package ctxlogtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/shogo82148/ctxlog"
+)
+
+type recorder struct {
+	testing.TB
+	logs   []string
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Log(args ...any) {
+	r.logs = append(r.logs, fmt.Sprint(args...))
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestFailOnError(t *testing.T) {
+	r := &recorder{TB: t}
+	l := FailOnError(r)
+	l.SetFlags(0)
+
+	l.Info(context.Background(), "hello", nil)
+	l.Error(context.Background(), "oops", nil)
+
+	if len(r.logs) != 1 || r.logs[0] != `{"level":"info","message":"hello"}` {
+		t.Errorf("unexpected logs: %q", r.logs)
+	}
+	if len(r.errors) != 1 || r.errors[0] != `unexpected error log: {"level":"error","message":"oops"}` {
+		t.Errorf("unexpected errors: %q", r.errors)
+	}
+}
+
+func TestFailOnLevel(t *testing.T) {
+	r := &recorder{TB: t}
+	l := FailOnLevel(r, ctxlog.LevelWarn)
+	l.SetFlags(0)
+
+	l.Warn(context.Background(), "careful", nil)
+	if len(r.errors) != 1 {
+		t.Errorf("unexpected errors: %q", r.errors)
+	}
+}
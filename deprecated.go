@@ -0,0 +1,42 @@
+package ctxlog
+
+import "context"
+
+// Deprecated writes a warn level entry that feature is deprecated,
+// with the "deprecated_feature" and "replacement" fields,
+// but only the first time it is called from the call site, like WarnOnce.
+// The replacement field is omitted if replacement is empty.
+//
+// It gives the library authors a consistent signal that can be aggregated by the fields:
+//
+//	func OldAPI(ctx context.Context) {
+//		ctxlog.Deprecated(ctx, "OldAPI", "NewAPI")
+//		...
+//	}
+func (l *Logger) Deprecated(ctx context.Context, feature, replacement string) {
+	if !l.Enabled(LevelWarn) {
+		return
+	}
+	msg, fields := deprecation(feature, replacement)
+	l.warnOnce(ctx, 3, msg, fields)
+}
+
+// Deprecated writes a warn level entry that feature is deprecated to the standard logger.
+// See Logger.Deprecated for details.
+func Deprecated(ctx context.Context, feature, replacement string) {
+	if !std.Enabled(LevelWarn) {
+		return
+	}
+	msg, fields := deprecation(feature, replacement)
+	std.warnOnce(ctx, 3, msg, fields)
+}
+
+func deprecation(feature, replacement string) (string, Fields) {
+	msg := feature + " is deprecated"
+	fields := Fields{"deprecated_feature": feature}
+	if replacement != "" {
+		msg += ", use " + replacement + " instead"
+		fields["replacement"] = replacement
+	}
+	return msg, fields
+}
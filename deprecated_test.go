@@ -0,0 +1,28 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestDeprecated(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lshortfile)
+
+	for i := 0; i < 3; i++ {
+		l.Deprecated(context.Background(), "OldAPI", "NewAPI")
+	}
+	want := `{"level":"warn","message":"OldAPI is deprecated, use NewAPI instead","file":"deprecated_test.go","line":14,"deprecated_feature":"OldAPI","replacement":"NewAPI"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	l.SetFlags(0)
+	l.Deprecated(context.Background(), "OldFlag", "")
+	want = `{"level":"warn","message":"OldFlag is deprecated","deprecated_feature":"OldFlag"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
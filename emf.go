@@ -0,0 +1,51 @@
+package ctxlog
+
+import "time"
+
+// EMFMetric declares a field as a metric of the CloudWatch embedded metric format.
+type EMFMetric struct {
+	// Name is the key of the field that has the value of the metric.
+	Name string `json:"Name"`
+
+	// Unit is the unit of the metric, such as "Milliseconds" and "Count".
+	Unit string `json:"Unit,omitempty"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []EMFMetric `json:"Metrics"`
+}
+
+// EMF returns the "_aws" field of the CloudWatch embedded metric format,
+// which makes CloudWatch extract the metrics from the entry.
+// metrics and dimensions refer to the keys of the other fields of the entry.
+//
+//	fields := ctxlog.EMF("my-service", []string{"operation"}, ctxlog.EMFMetric{Name: "latency_ms", Unit: "Milliseconds"})
+//	fields["operation"] = "upload"
+//	fields["latency_ms"] = 42
+//	ctxlog.Info(ctx, "uploaded", fields)
+//
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+func EMF(namespace string, dimensions []string, metrics ...EMFMetric) Fields {
+	if dimensions == nil {
+		dimensions = []string{}
+	}
+	return Fields{
+		"_aws": &emfMetadata{
+			Timestamp: time.Now().UnixMilli(),
+			CloudWatchMetrics: []emfMetricDirective{
+				{
+					Namespace:  namespace,
+					Dimensions: [][]string{dimensions},
+					Metrics:    append([]EMFMetric(nil), metrics...),
+				},
+			},
+		},
+	}
+}
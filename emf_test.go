@@ -0,0 +1,55 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEMF(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+
+	fields := EMF("my-service", []string{"operation"}, EMFMetric{Name: "latency_ms", Unit: "Milliseconds"})
+	fields["operation"] = "upload"
+	fields["latency_ms"] = 42
+	l.Info(context.Background(), "uploaded", fields)
+
+	if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		t.Errorf("the entry is not a single line: %q", buf.String())
+	}
+
+	var got struct {
+		AWS struct {
+			Timestamp         int64
+			CloudWatchMetrics []struct {
+				Namespace  string
+				Dimensions [][]string
+				Metrics    []EMFMetric
+			}
+		} `json:"_aws"`
+		LatencyMS int `json:"latency_ms"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.AWS.Timestamp == 0 {
+		t.Error("Timestamp is missing")
+	}
+	if len(got.AWS.CloudWatchMetrics) != 1 {
+		t.Fatalf("unexpected CloudWatchMetrics: %v", got.AWS.CloudWatchMetrics)
+	}
+	m := got.AWS.CloudWatchMetrics[0]
+	if m.Namespace != "my-service" {
+		t.Errorf("unexpected Namespace: got %q, want %q", m.Namespace, "my-service")
+	}
+	if want := [][]string{{"operation"}}; !reflect.DeepEqual(m.Dimensions, want) {
+		t.Errorf("unexpected Dimensions: got %v, want %v", m.Dimensions, want)
+	}
+	if want := []EMFMetric{{Name: "latency_ms", Unit: "Milliseconds"}}; !reflect.DeepEqual(m.Metrics, want) {
+		t.Errorf("unexpected Metrics: got %v, want %v", m.Metrics, want)
+	}
+}
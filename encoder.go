@@ -0,0 +1,73 @@
+package ctxlog
+
+import (
+	"io"
+	"sync"
+)
+
+// Encoder encodes entries into a stream, such as logfmt or msgpack.
+// It is an alternative to Formatter for the encoders that write to an io.Writer
+// rather than append to a buffer.
+type Encoder interface {
+	// EncodeRecord writes the encoded entry to w.
+	// w buffers the entry, and the logger writes it to the output in a single write.
+	EncodeRecord(w io.Writer, rec *Entry) error
+}
+
+// encoderFormatter adapts an Encoder to Formatter.
+type encoderFormatter struct {
+	enc Encoder
+}
+
+// appendWriter is an io.Writer that appends to a buffer.
+type appendWriter struct {
+	buf []byte
+}
+
+var appendWriterPool = sync.Pool{
+	New: func() any {
+		return new(appendWriter)
+	},
+}
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// WriteString implements io.StringWriter, so that io.WriteString doesn't allocate.
+func (w *appendWriter) WriteString(s string) (int, error) {
+	w.buf = append(w.buf, s...)
+	return len(s), nil
+}
+
+// Format implements Formatter.
+func (f encoderFormatter) Format(dst []byte, entry *Entry) ([]byte, error) {
+	// pool the writer, because passing it to the interface makes it escape.
+	w := appendWriterPool.Get().(*appendWriter)
+	w.buf = dst
+	err := f.enc.EncodeRecord(w, entry)
+	dst = w.buf
+	w.buf = nil
+	appendWriterPool.Put(w)
+	return dst, err
+}
+
+// SetEncoder sets the encoder of the logger.
+// It replaces the formatter set by SetFormatter.
+// If enc is nil, the logger uses JSONFormatter, which is the default.
+//
+// The fields of the entry are already merged, sorted and deduplicated,
+// so that the encoders only serialize them.
+func (l *Logger) SetEncoder(enc Encoder) {
+	if enc == nil {
+		l.SetFormatter(nil)
+		return
+	}
+	l.SetFormatter(encoderFormatter{enc: enc})
+}
+
+// SetEncoder sets the encoder of the standard logger.
+func SetEncoder(enc Encoder) {
+	std.SetEncoder(enc)
+}
@@ -0,0 +1,69 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// logfmtEncoder is a minimal Encoder of logfmt.
+type logfmtEncoder struct{}
+
+func (logfmtEncoder) EncodeRecord(w io.Writer, rec *Entry) error {
+	fmt.Fprintf(w, "level=%s msg=%q", rec.Level, rec.Message)
+	for _, f := range rec.Fields {
+		fmt.Fprintf(w, " %s=%v", f.Key, f.Value)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func TestSetEncoder(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetEncoder(logfmtEncoder{})
+
+	ctx := With(context.Background(), Fields{"user": "alice"})
+	l.Info(ctx, "hello world", Fields{"status": 200})
+	want := `level=info msg="hello world" status=200 user=alice` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+
+	// nil restores the default.
+	buf.Reset()
+	l.SetEncoder(nil)
+	l.Info(context.Background(), "hello", nil)
+	want = `{"level":"info","message":"hello"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
+
+// messageEncoder is an Encoder that writes only the message.
+type messageEncoder struct{}
+
+func (messageEncoder) EncodeRecord(w io.Writer, rec *Entry) error {
+	if _, err := io.WriteString(w, rec.Message); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func TestSetEncoder_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops the items randomly under the race detector")
+	}
+	l := New(discard, "", 0)
+	l.SetEncoder(messageEncoder{})
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Info(ctx, "test", nil)
+	})
+	if allocs != 0 {
+		t.Errorf("unexpected allocations: got %f, want 0", allocs)
+	}
+}
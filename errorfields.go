@@ -0,0 +1,101 @@
+package ctxlog
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxErrorCauses is the maximum number of the causes in the "error.causes" field.
+const maxErrorCauses = 16
+
+// ErrorErr writes the output for an error level logging event with err expanded into the fields:
+//
+//   - "error": the message of err
+//   - "error.type": the type of err, such as "*fs.PathError"
+//   - "error.causes": the messages of the errors wrapped by err, from the outermost
+//   - "error.stack": the stack trace carried by err, if any, see SetStackTracePolicy
+//
+// The fields of err have higher precedence than fields.
+// If err is nil, ErrorErr is equivalent to Error.
+func (l *Logger) ErrorErr(ctx context.Context, msg string, err error, fields Fields) {
+	if !l.Enabled(LevelError) {
+		return
+	}
+	l.output(ctx, 2, LevelError, msg, errorKVs(err), fields)
+}
+
+// ErrorErr writes the output for an error level logging event with err expanded into the fields
+// to the standard logger. See Logger.ErrorErr for details.
+func ErrorErr(ctx context.Context, msg string, err error, fields Fields) {
+	if !std.Enabled(LevelError) {
+		return
+	}
+	std.output(ctx, 2, LevelError, msg, errorKVs(err), fields)
+}
+
+// errorKVs returns the fields of err for ErrorErr.
+func errorKVs(err error) []KV {
+	if err == nil {
+		return nil
+	}
+	if isNilPointer(err) {
+		// the methods of the nil pointer may panic.
+		return []KV{
+			{Key: "error", Value: nil},
+			{Key: "error.type", Value: fmt.Sprintf("%T", err)},
+		}
+	}
+	kvs := []KV{
+		{Key: "error", Value: err.Error()},
+		{Key: "error.type", Value: fmt.Sprintf("%T", err)},
+	}
+
+	causes := wrappedErrors(nil, err)
+	if len(causes) > 0 {
+		msgs := make([]string, 0, len(causes))
+		for _, e := range causes {
+			if isNilPointer(e) {
+				msgs = append(msgs, "<nil>")
+				continue
+			}
+			msgs = append(msgs, e.Error())
+		}
+		kvs = append(kvs, KV{Key: "error.causes", Value: msgs})
+	}
+
+	// the errors joined by errors.Join may carry the stack trace, but errors.Unwrap doesn't find them.
+	for _, e := range append([]error{err}, causes...) {
+		if st, ok := carriedStackTrace(e); ok {
+			kvs = append(kvs, KV{Key: "error.stack", Value: st})
+			break
+		}
+	}
+	return kvs
+}
+
+// wrappedErrors appends the errors wrapped by err in depth-first order,
+// including the ones joined by errors.Join.
+func wrappedErrors(errs []error, err error) []error {
+	var wrapped []error
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if e := u.Unwrap(); e != nil {
+			wrapped = []error{e}
+		}
+	case interface{ Unwrap() []error }:
+		wrapped = u.Unwrap()
+	}
+	for _, e := range wrapped {
+		if len(errs) >= maxErrorCauses {
+			break
+		}
+		if e == nil {
+			continue
+		}
+		errs = append(errs, e)
+		if !isNilPointer(e) {
+			errs = wrappedErrors(errs, e)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,61 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorErr(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	ctx := context.Background()
+
+	err := fmt.Errorf("query: %w", errors.Join(errors.New("timeout"), fmt.Errorf("retry: %w", testStackError{})))
+	l.ErrorErr(ctx, "failed", err, Fields{"error": "overridden", "user": "alice"})
+	l.ErrorErr(ctx, "no error", nil, Fields{"user": "alice"})
+
+	want := `{"level":"error","message":"failed","error":"query: timeout\nretry: stack error",` +
+		`"error.causes":["timeout\nretry: stack error","timeout","retry: stack error","stack error"],` +
+		`"error.stack":"[main.main runtime.main]","error.type":"*fmt.wrapError","user":"alice"}` + "\n" +
+		`{"level":"error","message":"no error","user":"alice"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestErrorErr_NilPointer(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lerrorstack)
+	l.SetStackTracePolicy(StackTraceConditional)
+	RegisterStackTraceType(&testNilError{})
+	ctx := context.Background()
+
+	var err *testNilError
+	l.ErrorErr(ctx, "nil pointer", err, nil)
+	l.ErrorErr(ctx, "wrapped", fmt.Errorf("query: %w", err), nil)
+
+	got := buf.String()
+	for _, want := range []string{
+		`"message":"nil pointer","error":null,`,
+		`"error.type":"*ctxlog.testNilError"`,
+		`"error.causes":["\u003cnil\u003e"]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %s in the output, got %s", want, got)
+		}
+	}
+}
+
+func TestErrorErr_Disabled(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetLevel(LevelFatal)
+	l.ErrorErr(context.Background(), "failed", errors.New("error"), nil)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
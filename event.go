@@ -0,0 +1,145 @@
+package ctxlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+)
+
+// EventLevel returns the level of the entries logged by Event.
+func (l *Logger) EventLevel() Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.eventLevel
+}
+
+// SetEventLevel sets the level of the entries logged by Event.
+// The default is LevelInfo.
+func (l *Logger) SetEventLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.eventLevel = level
+}
+
+// EventOutput returns the output destination of the entries logged by Event.
+// If it is not set, the events are written to the output of the logger.
+func (l *Logger) EventOutput() io.Writer {
+	l.mu.RLock()
+	w := l.eventOut
+	l.mu.RUnlock()
+	if w == nil {
+		return l.Writer()
+	}
+	return w
+}
+
+// SetEventOutput sets the output destination of the entries logged by Event,
+// such as a named pipe for the stream of machine-readable events.
+// If it is nil, which is the default, the events are written to the output of the logger.
+func (l *Logger) SetEventOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.eventOut = w
+}
+
+// EventFormatter returns the formatter of the entries logged by Event.
+func (l *Logger) EventFormatter() Formatter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.eventFormat != nil {
+		return l.eventFormat
+	}
+	if l.formatter != nil {
+		return l.formatter
+	}
+	return defaultFormatter
+}
+
+// SetEventFormatter sets the formatter of the entries logged by Event.
+// If it is nil, which is the default, the events are formatted by the formatter of the logger.
+func (l *Logger) SetEventFormatter(f Formatter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.eventFormat = f
+}
+
+// Event writes the output for a named structured event, which mirrors a span event of tracing.
+// The entry carries name as the message and the "event" field, and attrs as the fields.
+// The "event" field has precedence over attrs and the fields of the context.
+// The entry is written to the event output, see SetEventOutput.
+func (l *Logger) Event(ctx context.Context, name string, attrs Fields) {
+	if l.discarding() && l.EventOutput() == io.Discard {
+		return
+	}
+	l.event(ctx, 3, name, attrs)
+}
+
+func (l *Logger) event(ctx context.Context, calldepth int, name string, attrs Fields) error {
+	level := l.EventLevel()
+	ok, sampled := l.enabled(level, name)
+	if !ok {
+		return nil
+	}
+	extra := []KV{{Key: "event", Value: name}}
+	if sampled > 0 {
+		extra = appendSampled(extra, sampled)
+	}
+	return l.emit(ctx, calldepth, level, name, extra, attrs, l.EventOutput(), l.EventFormatter(), false)
+}
+
+// Event writes the output for a named structured event, which mirrors a span event of tracing.
+// The entry carries name as the message and the "event" field, and attrs as the fields.
+// The "event" field has precedence over attrs and the fields of the context.
+// The entry is written to the event output, see SetEventOutput.
+func Event(ctx context.Context, name string, attrs Fields) {
+	if std.discarding() && std.EventOutput() == io.Discard {
+		return
+	}
+	std.event(ctx, 3, name, attrs)
+}
+
+// eventsFDEnv is the environment variable that names the file descriptor of the stream of events, such as "3".
+const eventsFDEnv = "CTXLOG_EVENTS_FD"
+
+// NewSplit returns a new Logger for containers that follow the "metrics as a separate stream" pattern.
+// The logs are written to os.Stderr in human-readable text,
+// and the events logged by Event are written in JSON to the file descriptor
+// named by the CTXLOG_EVENTS_FD environment variable, conventionally 3.
+// If the variable is not set, or the file descriptor is not a pipe or a regular file,
+// the events are written to os.Stderr as well.
+// The logger writes to a duplicate of the file descriptor, and closes only the duplicate.
+// CTXLOG_EVENTS_FD is ignored on the platforms other than Unix, except for 1 and 2.
+//
+// Use SetEventOutput to write the events to another destination, such as a named pipe.
+func NewSplit() *Logger {
+	l := New(os.Stderr, "", LstdFlags)
+	l.SetFormatter(NewTextFormatter(os.Stderr))
+	l.SetEventFormatter(&JSONFormatter{})
+	if f := eventsFile(); f != nil {
+		l.SetEventOutput(f)
+	}
+	return l
+}
+
+// eventsFile returns the file of the stream of events named by the CTXLOG_EVENTS_FD environment variable.
+// It returns nil if the variable is not set or the file is not a pipe or a regular file.
+// The file descriptor is opt-in, because the file descriptors that the process doesn't know,
+// such as the one used by the runtime for polling, must not be written to.
+// The file descriptors 1 and 2 are mapped to os.Stdout and os.Stderr, so that Close doesn't close them.
+func eventsFile() *os.File {
+	fd, err := strconv.Atoi(os.Getenv(eventsFDEnv))
+	if err != nil {
+		return nil
+	}
+	switch fd {
+	case 1:
+		return os.Stdout
+	case 2:
+		return os.Stderr
+	}
+	if fd < 3 {
+		return nil
+	}
+	return dupEventsFile(fd)
+}
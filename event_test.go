@@ -0,0 +1,67 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+)
+
+func TestEvent(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lshortfile)
+
+	l.Event(context.Background(), "cache.miss", Fields{"key": "user:1", "event": "overridden"})
+	want := `{"level":"info","message":"cache.miss","file":"event_test.go","line":14,"event":"cache.miss","key":"user:1"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	l.SetLevel(LevelInfo)
+	l.SetEventLevel(LevelDebug)
+	l.Event(context.Background(), "cache.miss", nil)
+	if buf.Len() != 0 {
+		t.Errorf("want no output, got %q", buf.String())
+	}
+}
+
+func TestSetEventOutput(t *testing.T) {
+	logs := new(bytes.Buffer)
+	events := new(bytes.Buffer)
+	l := New(logs, "", 0)
+	l.SetFormatter(&TextFormatter{})
+	l.SetEventOutput(events)
+	l.SetEventFormatter(&JSONFormatter{})
+
+	l.Info(context.Background(), "hello", nil)
+	l.Event(context.Background(), "cache.miss", Fields{"key": "user:1"})
+
+	if got, want := logs.String(), "INFO hello\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	want := `{"level":"info","message":"cache.miss","event":"cache.miss","key":"user:1"}` + "\n"
+	if got := events.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+	}{
+		{"unset", ""},
+		{"invalid", "events"},
+		{"negative", "-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(eventsFDEnv, tt.env)
+			l := NewSplit()
+			if got := l.EventOutput(); got != os.Stderr {
+				t.Errorf("want the events written to os.Stderr, got %v", got)
+			}
+		})
+	}
+}
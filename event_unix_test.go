@@ -0,0 +1,90 @@
+//go:build unix
+
+package ctxlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNewSplit_EventsFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	t.Setenv(eventsFDEnv, strconv.Itoa(int(w.Fd())))
+
+	l := NewSplit()
+	if _, ok := l.EventOutput().(*os.File); !ok {
+		t.Fatalf("want the events written to the pipe, got %v", l.EventOutput())
+	}
+	l.Event(context.Background(), "cache.miss", nil)
+
+	// Close closes only the duplicate.
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("after close\n")); err != nil {
+		t.Errorf("the original file descriptor is closed: %v", err)
+	}
+	w.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"event":"cache.miss"`; !strings.Contains(string(got), want) {
+		t.Errorf("want %s in the events, got %q", want, got)
+	}
+}
+
+func TestNewSplit_EventsFDRejected(t *testing.T) {
+	dir, err := os.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Close()
+	fd := int(dir.Fd())
+	t.Setenv(eventsFDEnv, strconv.Itoa(fd))
+
+	if f := eventsFile(); f != nil {
+		t.Error("want a directory rejected, got accepted")
+	}
+
+	// the rejected file descriptor is kept open.
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond) // wait for the finalizers.
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		t.Errorf("the rejected file descriptor is closed: %v", err)
+	}
+}
+
+func TestNewSplit_EventsFDStd(t *testing.T) {
+	tests := []struct {
+		env  string
+		want *os.File
+	}{
+		{"1", os.Stdout},
+		{"2", os.Stderr},
+	}
+	for _, tt := range tests {
+		t.Setenv(eventsFDEnv, tt.env)
+		if got := eventsFile(); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.env, got, tt.want)
+		}
+	}
+	t.Setenv(eventsFDEnv, "0")
+	if got := eventsFile(); got != nil {
+		t.Errorf("0: want rejected, got %v", got)
+	}
+}
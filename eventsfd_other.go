@@ -0,0 +1,10 @@
+//go:build !unix
+
+package ctxlog
+
+import "os"
+
+// dupEventsFile returns nil, because the file descriptors are not portable.
+func dupEventsFile(fd int) *os.File {
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package ctxlog
+
+import (
+	"os"
+	"syscall"
+)
+
+// dupEventsFile returns the file of a duplicate of fd if fd is a pipe or a regular file.
+// fd itself is never closed, even if it is rejected.
+func dupEventsFile(fd int) *os.File {
+	syscall.ForkLock.RLock()
+	dup, err := syscall.Dup(fd)
+	if err == nil {
+		syscall.CloseOnExec(dup)
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return nil
+	}
+
+	f := os.NewFile(uintptr(dup), "events")
+	fi, err := f.Stat()
+	if err != nil || (!fi.Mode().IsRegular() && fi.Mode()&os.ModeNamedPipe == 0) {
+		f.Close() // closes only the duplicate.
+		return nil
+	}
+	return f
+}
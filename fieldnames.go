@@ -0,0 +1,69 @@
+package ctxlog
+
+// FieldNames is the keys of the reserved fields.
+// The empty keys are the default names.
+type FieldNames struct {
+	Time    string // default "time"
+	Level   string // default "level"
+	Message string // default "message"
+	File    string // default "file"
+	Line    string // default "line"
+}
+
+var defaultFieldNames = FieldNames{
+	Time:    "time",
+	Level:   "level",
+	Message: "message",
+	File:    "file",
+	Line:    "line",
+}
+
+// withDefaults returns n with the empty keys replaced by the default names.
+func (n FieldNames) withDefaults() FieldNames {
+	if n.Time == "" {
+		n.Time = defaultFieldNames.Time
+	}
+	if n.Level == "" {
+		n.Level = defaultFieldNames.Level
+	}
+	if n.Message == "" {
+		n.Message = defaultFieldNames.Message
+	}
+	if n.File == "" {
+		n.File = defaultFieldNames.File
+	}
+	if n.Line == "" {
+		n.Line = defaultFieldNames.Line
+	}
+	return n
+}
+
+// reserved reports whether key conflicts with the reserved fields.
+func (n *FieldNames) reserved(key string) bool {
+	return key == n.Time || key == n.Level || key == n.Message || key == n.File || key == n.Line
+}
+
+// FieldNames returns the keys of the reserved fields.
+func (l *Logger) FieldNames() FieldNames {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.names.withDefaults()
+}
+
+// SetFieldNames sets the keys of the reserved fields written by JSONFormatter,
+// e.g. for the ingestion pipelines that expect "@timestamp", "severity" and "msg":
+//
+//	logger.SetFieldNames(ctxlog.FieldNames{Time: "@timestamp", Level: "severity", Message: "msg"})
+//
+// The user fields that conflict with the configured keys are prefixed with "field.",
+// and the fields named like the default keys are written as is.
+func (l *Logger) SetFieldNames(names FieldNames) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.names = names
+}
+
+// SetFieldNames sets the keys of the reserved fields of the standard logger.
+func SetFieldNames(names FieldNames) {
+	std.SetFieldNames(names)
+}
@@ -0,0 +1,26 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSetFieldNames(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lshortfile)
+	l.SetFieldNames(FieldNames{Time: "@timestamp", Level: "severity", Message: "msg"})
+	ctx := context.Background()
+
+	// "time" and "message" don't conflict with the configured names, but "msg" does.
+	l.Info(ctx, "hello", Fields{"time": "user", "message": "user", "msg": "user"})
+	want := `{"severity":"info","msg":"hello","file":"fieldnames_test.go","line":16,"message":"user","field.msg":"user","time":"user"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+
+	got := l.FieldNames()
+	if got.Time != "@timestamp" || got.File != "file" {
+		t.Errorf("unexpected field names: %+v", got)
+	}
+}
@@ -0,0 +1,127 @@
+package ctxlog
+
+import "context"
+
+// FieldOrder controls the order of the fields in the output.
+type FieldOrder int
+
+const (
+	// FieldOrderSorted sorts the fields by key, which is the default.
+	// It makes the entries easy to diff.
+	FieldOrderSorted FieldOrder = iota
+
+	// FieldOrderInsertion keeps the fields in the order that they are added:
+	// the fields passed to the KV variants of the output functions, such as InfoKV, in their order,
+	// then the per-call fields, the context fields, the bound fields and so on, in the order of the precedence.
+	// The order of the keys in a Fields map is random, so use the KV variants for the stable order.
+	FieldOrderInsertion
+)
+
+// FieldOrder returns the order of the fields in the output.
+func (l *Logger) FieldOrder() FieldOrder {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.fieldOrder
+}
+
+// SetFieldOrder sets the order of the fields in the output.
+// The keys that conflict with the reserved fields are prefixed with "field." in either order,
+// and SetSchema and SetPriorityFields take effect after the order.
+//
+//	logger.SetFieldOrder(ctxlog.FieldOrderInsertion)
+//	logger.InfoKV(ctx, "checkout", ctxlog.KV{Key: "event", Value: "purchase"}, ctxlog.KV{Key: "amount", Value: 42})
+//	// {"level":"info","message":"checkout","event":"purchase","amount":42}
+func (l *Logger) SetFieldOrder(order FieldOrder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fieldOrder = order
+}
+
+// SetFieldOrder sets the order of the fields in the output of the standard logger.
+func SetFieldOrder(order FieldOrder) {
+	std.SetFieldOrder(order)
+}
+
+// The KV variants of the output functions, such as InfoKV, take the fields as an ordered list,
+// which keeps the order with FieldOrderInsertion.
+// The fields have the highest precedence, like the per-call fields of the other output functions.
+
+// TraceKV writes the output for a trace level logging event with the ordered fields.
+func (l *Logger) TraceKV(ctx context.Context, msg string, fields ...KV) {
+	if !l.Enabled(LevelTrace) {
+		return
+	}
+	l.output(ctx, 2, LevelTrace, msg, fields, nil)
+}
+
+// DebugKV writes the output for a debug level logging event with the ordered fields.
+func (l *Logger) DebugKV(ctx context.Context, msg string, fields ...KV) {
+	if !l.Enabled(LevelDebug) {
+		return
+	}
+	l.output(ctx, 2, LevelDebug, msg, fields, nil)
+}
+
+// InfoKV writes the output for an info level logging event with the ordered fields.
+func (l *Logger) InfoKV(ctx context.Context, msg string, fields ...KV) {
+	if !l.Enabled(LevelInfo) {
+		return
+	}
+	l.output(ctx, 2, LevelInfo, msg, fields, nil)
+}
+
+// WarnKV writes the output for a warn level logging event with the ordered fields.
+func (l *Logger) WarnKV(ctx context.Context, msg string, fields ...KV) {
+	if !l.Enabled(LevelWarn) {
+		return
+	}
+	l.output(ctx, 2, LevelWarn, msg, fields, nil)
+}
+
+// ErrorKV writes the output for an error level logging event with the ordered fields.
+func (l *Logger) ErrorKV(ctx context.Context, msg string, fields ...KV) {
+	if !l.Enabled(LevelError) {
+		return
+	}
+	l.output(ctx, 2, LevelError, msg, fields, nil)
+}
+
+// TraceKV writes the output for a trace level logging event with the ordered fields.
+func TraceKV(ctx context.Context, msg string, fields ...KV) {
+	if !std.Enabled(LevelTrace) {
+		return
+	}
+	std.output(ctx, 2, LevelTrace, msg, fields, nil)
+}
+
+// DebugKV writes the output for a debug level logging event with the ordered fields.
+func DebugKV(ctx context.Context, msg string, fields ...KV) {
+	if !std.Enabled(LevelDebug) {
+		return
+	}
+	std.output(ctx, 2, LevelDebug, msg, fields, nil)
+}
+
+// InfoKV writes the output for an info level logging event with the ordered fields.
+func InfoKV(ctx context.Context, msg string, fields ...KV) {
+	if !std.Enabled(LevelInfo) {
+		return
+	}
+	std.output(ctx, 2, LevelInfo, msg, fields, nil)
+}
+
+// WarnKV writes the output for a warn level logging event with the ordered fields.
+func WarnKV(ctx context.Context, msg string, fields ...KV) {
+	if !std.Enabled(LevelWarn) {
+		return
+	}
+	std.output(ctx, 2, LevelWarn, msg, fields, nil)
+}
+
+// ErrorKV writes the output for an error level logging event with the ordered fields.
+func ErrorKV(ctx context.Context, msg string, fields ...KV) {
+	if !std.Enabled(LevelError) {
+		return
+	}
+	std.output(ctx, 2, LevelError, msg, fields, nil)
+}
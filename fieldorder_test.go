@@ -0,0 +1,120 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSetFieldOrder(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	ctx := With(context.Background(), Fields{"b": "context", "user": "alice"})
+
+	// the fields are sorted by default.
+	l.InfoKV(ctx, "hello", KV{Key: "event", Value: "purchase"}, KV{Key: "b", Value: "call"}, KV{Key: "level", Value: 1})
+	want := `{"level":"info","message":"hello","b":"call","event":"purchase","field.level":1,"user":"alice"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	l.SetFieldOrder(FieldOrderInsertion)
+	l.InfoKV(ctx, "hello", KV{Key: "event", Value: "purchase"}, KV{Key: "b", Value: "call"}, KV{Key: "level", Value: 1})
+	want = `{"level":"info","message":"hello","event":"purchase","b":"call","field.level":1,"user":"alice"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
+
+func TestSetFieldOrder_Merge(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetFieldOrder(FieldOrderInsertion)
+	l.SetMergeFunc(func(key string, parent, child any) any {
+		return parent.(string) + "," + child.(string)
+	})
+	ctx := With(context.Background(), Fields{"tags": "outer"})
+	ctx = With(ctx, Fields{"tags": "inner"})
+
+	l.InfoKV(ctx, "hello", KV{Key: "z", Value: "first"}, KV{Key: "tags", Value: "call"})
+	want := `{"level":"info","message":"hello","z":"first","tags":"outer,inner,call"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
+
+// jsonKeys returns the keys of the JSON object in line, in the order of appearance.
+func jsonKeys(t *testing.T, line []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if _, err := dec.Token(); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, tok.(string))
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func TestSetFieldOrder_Fingerprint(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lfingerprint)
+	l.SetFieldOrder(FieldOrderInsertion)
+	l.SetFingerprintFields("a")
+
+	ctx := context.Background()
+	l.InfoKV(ctx, "hello", KV{Key: "z", Value: 0}, KV{Key: "a", Value: 1})
+	l.InfoKV(ctx, "hello", KV{Key: "z", Value: 0}, KV{Key: "a", Value: 2}, KV{Key: "fingerprint", Value: "user"})
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %d", len(lines))
+	}
+	var got [2]struct{ Fingerprint string }
+	for i, line := range lines {
+		if err := json.Unmarshal(line, &got[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got[0].Fingerprint == got[1].Fingerprint {
+		t.Errorf("want different fingerprints for a=1 and a=2, got %q", got[0].Fingerprint)
+	}
+	wantKeys := []string{"level", "message", "z", "a", "fingerprint"}
+	for _, line := range lines {
+		if keys := jsonKeys(t, line); !reflect.DeepEqual(keys, wantKeys) {
+			t.Errorf("unexpected keys: got %v, want %v", keys, wantKeys)
+		}
+	}
+}
+
+func TestSetFieldOrder_Hook(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetFieldOrder(FieldOrderInsertion)
+	l.SetStackTracePolicy(StackTraceOnError)
+	l.AddHook(func(level Level, fields map[string]any) {
+		fields["hooked"] = true
+		fields["added"] = true
+		delete(fields, "m")
+	})
+
+	l.ErrorKV(context.Background(), "failed", KV{Key: "z", Value: 0}, KV{Key: "m", Value: 1}, KV{Key: "a", Value: 2}, KV{Key: "stack", Value: "user"})
+
+	got := jsonKeys(t, buf.Bytes())
+	want := []string{"level", "message", "z", "a", "stack", "added", "hooked"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected keys: got %v, want %v", got, want)
+	}
+}
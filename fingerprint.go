@@ -0,0 +1,106 @@
+package ctxlog
+
+import "sort"
+
+// FingerprintFields returns the keys of the fields included in the fingerprint.
+func (l *Logger) FingerprintFields() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.fingerprint
+}
+
+// SetFingerprintFields sets the keys of the fields included in the fingerprint.
+// If the Lfingerprint flag is set, every entry carries the "fingerprint" field,
+// which is a FNV-1a hash of the message and the fields, so that the same logical event
+// gets the same fingerprint across retries and process runs.
+func (l *Logger) SetFingerprintFields(keys ...string) {
+	keys = append([]string(nil), keys...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fingerprint = keys
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+func fnvString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
+func fnvBytes(h uint64, b []byte) uint64 {
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// fingerprint returns the hash of msg and the fields of keys in fields.
+// fields must be in the order of e, see searchField.
+// It uses the buffer of e to encode the values of the fields.
+func (e *encodeState) fingerprint(msg string, fields []KV, keys []string) string {
+	h := uint64(fnvOffset64)
+	h = fnvString(h, msg)
+	for _, key := range keys {
+		i, ok := e.searchField(fields, key)
+		if !ok {
+			continue
+		}
+		e.Reset()
+		if err := e.appendAny(fields[i].Value); err != nil {
+			continue
+		}
+		h = fnvString(h, "\x00")
+		h = fnvString(h, key)
+		h = fnvString(h, "\x00")
+		h = fnvBytes(h, e.Bytes())
+	}
+
+	const hex = "0123456789abcdef"
+	var buf [16]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = hex[h&0xf]
+		h >>= 4
+	}
+	return string(buf[:])
+}
+
+// searchField returns the index of key in fields, and reports whether it is found.
+// If it is not found, the index is where key would be inserted.
+// fields are sorted by key, unless they are in the insertion order, see FieldOrderInsertion.
+func (e *encodeState) searchField(fields []KV, key string) (int, bool) {
+	if e.insertion {
+		for i, f := range fields {
+			if f.Key == key {
+				return i, true
+			}
+		}
+		return len(fields), false
+	}
+	i := sort.Search(len(fields), func(i int) bool { return fields[i].Key >= key })
+	return i, i < len(fields) && fields[i].Key == key
+}
+
+// insertField inserts the field into fields, in the order of e, see searchField.
+// If the key already exists, its value is replaced.
+func (e *encodeState) insertField(fields []KV, key string, value any) []KV {
+	i, ok := e.searchField(fields, key)
+	if ok {
+		fields[i].Value = value
+		return fields
+	}
+	fields = append(fields, KV{})
+	copy(fields[i+1:], fields[i:])
+	fields[i] = KV{Key: key, Value: value}
+	if len(fields) > len(e.kv) {
+		e.kv = fields
+	}
+	return fields
+}
@@ -0,0 +1,74 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := func(msg string, fields Fields) string {
+		buf := new(bytes.Buffer)
+		l := New(buf, "", LstdFlags|Lfingerprint)
+		l.SetFingerprintFields("operation", "code")
+		l.Error(context.Background(), msg, fields)
+
+		var got struct {
+			Fingerprint string
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Fingerprint) != 16 {
+			t.Errorf("unexpected fingerprint: %q", got.Fingerprint)
+		}
+		return got.Fingerprint
+	}
+
+	a := fingerprint("failed", Fields{"operation": "upload", "code": 500, "attempt": 1})
+	b := fingerprint("failed", Fields{"operation": "upload", "code": 500, "attempt": 2})
+	if a != b {
+		t.Errorf("the fingerprints of the same event differ: %q and %q", a, b)
+	}
+
+	c := fingerprint("failed", Fields{"operation": "download", "code": 500, "attempt": 1})
+	if a == c {
+		t.Errorf("the fingerprints of different events are the same: %q", a)
+	}
+
+	d := fingerprint("timeout", Fields{"operation": "upload", "code": 500, "attempt": 1})
+	if a == d {
+		t.Errorf("the fingerprints of different messages are the same: %q", a)
+	}
+}
+
+func TestFingerprint_UTC(t *testing.T) {
+	at := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("JST", 9*60*60))
+	fingerprint := func(flag int) string {
+		buf := new(bytes.Buffer)
+		l := New(buf, "", flag|Lfingerprint)
+		l.SetFingerprintFields("at")
+		l.Info(context.Background(), "hello", Fields{"at": at})
+
+		var got struct {
+			Fingerprint string
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Fingerprint
+	}
+
+	// the encoder is reused by the entries with the different flags.
+	a := fingerprint(0)
+	utc := fingerprint(LUTC)
+	b := fingerprint(0)
+	if a != b {
+		t.Errorf("the fingerprints of the same event differ: %q and %q", a, b)
+	}
+	if a == utc {
+		t.Errorf("want the fingerprint of the time in UTC different, got the same %q", a)
+	}
+}
@@ -0,0 +1,319 @@
+package ctxlog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+// Entry is a logging event passed to a Formatter.
+type Entry struct {
+	// Time is the time when the event occurred.
+	Time time.Time
+
+	// Level is the level of the event.
+	Level Level
+
+	// Message is the message of the event.
+	// The prefix of the logger is already applied.
+	Message string
+
+	// File and Line are the location of the caller.
+	// They are set if Flags has Lshortfile or Llongfile.
+	File string
+	Line int
+
+	// Flags is the output flags of the logger.
+	Flags int
+
+	// Fields is the merged fields sorted by key,
+	// following the priority fields of the logger and the fields of the schema.
+	// The keys that conflict with the reserved fields are already prefixed with "field.".
+	Fields []KV
+
+	timeLayout  string        // layout of time.Time values in Fields
+	timeFormat  TimeFormatter // format of Time
+	levelFormat LevelFormat   // format of Level
+	colorLevel  Level         // the minimum level to be colored
+	names       FieldNames    // keys of the reserved fields
+	rootKey     string        // key of the object that the fields are nested under
+}
+
+// Formatter formats entries.
+type Formatter interface {
+	// Format appends the formatted entry to dst and returns the extended buffer.
+	// If some fields fail to encode, it may return the entry with placeholders
+	// in place of their values, together with a non-nil error.
+	Format(dst []byte, e *Entry) ([]byte, error)
+}
+
+var defaultFormatter Formatter = &JSONFormatter{}
+
+// JSONFormatter formats entries in JSON.
+// It is the default formatter.
+type JSONFormatter struct {
+	// NestedCaller emits the caller as a nested object such as {"caller":{"file":"d.go","line":23}}
+	// instead of the "file" and "line" fields.
+	// A user field named "caller" is prefixed with "field.".
+	NestedCaller bool
+
+	// Fragment omits the outer braces of the JSON object,
+	// so that the output can be embedded into an enclosing JSON document, such as:
+	//
+	//	"level":"info","message":"hello","key":"value"
+	//
+	// The output is not valid JSON by itself.
+	// It is intended for custom sinks that wrap the entries with envelope fields.
+	// The trailing newline is kept as the separator of entries.
+	Fragment bool
+}
+
+var _ Formatter = (*JSONFormatter)(nil)
+
+var nestedCallerReserved = []string{"caller"}
+
+// Format implements Formatter.
+func (f *JSONFormatter) Format(dst []byte, entry *Entry) ([]byte, error) {
+	e := encodeStatePool.Get().(*encodeState)
+	defer encodeStatePool.Put(e)
+	e.resetEntry(entry)
+
+	if !f.Fragment {
+		e.WriteByte('{')
+	}
+
+	names := entry.names.withDefaults()
+	if entry.Flags&(Ldate|Ltime|Lmicroseconds) != 0 {
+		e.appendString(names.Time)
+		e.WriteByte(':')
+		if entry.timeFormat != nil {
+			t := entry.Time
+			if entry.Flags&LUTC != 0 {
+				t = t.UTC()
+			}
+			e.Write(entry.timeFormat(e.scratch[:0], t))
+		} else {
+			e.WriteByte('"')
+			e.appendTime(entry.Flags, entry.Time)
+			e.WriteByte('"')
+		}
+		e.WriteByte(',')
+	}
+
+	e.appendString(names.Level)
+	e.WriteByte(':')
+	e.appendString(entry.levelFormat.format(entry.Level))
+	e.WriteByte(',')
+
+	e.appendString(names.Message)
+	e.WriteByte(':')
+	e.appendString(entry.Message)
+
+	hasCaller := entry.Flags&(Lshortfile|Llongfile) != 0
+	if hasCaller {
+		e.WriteByte(',')
+		if f.NestedCaller {
+			e.appendString("caller")
+			e.WriteString(":{")
+		}
+		e.appendString(names.File)
+		e.WriteByte(':')
+		e.appendString(entry.File)
+		e.WriteByte(',')
+		e.appendString(names.Line)
+		e.WriteByte(':')
+		e.appendInt(int64(entry.Line))
+		if f.NestedCaller {
+			e.WriteByte('}')
+		}
+	}
+
+	var reserved []string
+	if hasCaller && f.NestedCaller {
+		reserved = nestedCallerReserved
+	}
+	var err error
+	if entry.rootKey != "" {
+		if len(entry.Fields) > 0 {
+			err = e.writeNestedFields(entry.rootKey, entry.Fields)
+		}
+	} else {
+		err = e.writeFields(entry.Fields, reserved...)
+	}
+
+	if !f.Fragment {
+		e.WriteByte('}')
+	}
+	e.WriteByte('\n')
+	return append(dst, e.Bytes()...), err
+}
+
+// TextFormatter formats entries in a human-readable form such as:
+//
+//	2001-02-03T04:05:06Z INFO message key=value key2=value2
+//
+// The level is in upper case unless the level format of the logger is LevelShort.
+type TextFormatter struct {
+	// EnableColor colors the level with ANSI escape sequences:
+	// trace and debug are gray, warn is yellow, and error, fatal and panic are red.
+	// Only the level is colored, so the rest of the line is the same as the uncolored one.
+	EnableColor bool
+}
+
+// NewTextFormatter returns a new TextFormatter for out.
+// The level is colored if out is a terminal, unless the NO_COLOR environment variable is set.
+func NewTextFormatter(out io.Writer) *TextFormatter {
+	return &TextFormatter{EnableColor: colorEnabled(out)}
+}
+
+// colorEnabled reports whether the output to w should be colored.
+// See https://no-color.org/ for NO_COLOR.
+func colorEnabled(w io.Writer) bool {
+	return isTerminal(w) && os.Getenv("NO_COLOR") == ""
+}
+
+var _ Formatter = (*TextFormatter)(nil)
+
+const colorReset = "\x1b[0m"
+
+func levelColor(level Level) string {
+	switch level {
+	case LevelTrace, LevelDebug:
+		return "\x1b[90m" // gray
+	case LevelWarn:
+		return "\x1b[33m" // yellow
+	case LevelError, LevelFatal, LevelPanic:
+		return "\x1b[31m" // red
+	}
+	if level < LevelTrace {
+		return "\x1b[90m" // gray
+	}
+	return ""
+}
+
+func levelUpper(level Level) string {
+	switch level {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "FATAL"
+	case LevelPanic:
+		return "PANIC"
+	case LevelNo:
+		return "NO"
+	case LevelDisabled:
+		return "DISABLED"
+	}
+	return "TRACE"
+}
+
+// Format implements Formatter.
+func (f *TextFormatter) Format(dst []byte, entry *Entry) ([]byte, error) {
+	e := encodeStatePool.Get().(*encodeState)
+	defer encodeStatePool.Put(e)
+	e.resetEntry(entry)
+
+	if entry.Flags&(Ldate|Ltime|Lmicroseconds) != 0 {
+		e.appendTime(entry.Flags, entry.Time)
+		e.WriteByte(' ')
+	}
+
+	level := levelUpper(entry.Level)
+	if entry.levelFormat == LevelShort {
+		level = levelShort(entry.Level)
+	}
+	color := ""
+	if f.EnableColor && entry.Level >= entry.colorLevel {
+		color = levelColor(entry.Level)
+	}
+	if color != "" {
+		e.WriteString(color)
+		e.WriteString(level)
+		e.WriteString(colorReset)
+	} else {
+		e.WriteString(level)
+	}
+
+	if entry.Flags&(Lshortfile|Llongfile) != 0 {
+		e.WriteByte(' ')
+		e.appendTextMessage(entry.File)
+		e.WriteByte(':')
+		e.appendInt(int64(entry.Line))
+	}
+
+	e.WriteByte(' ')
+	e.appendTextMessage(entry.Message)
+	err := e.appendTextFields(entry.Fields)
+	e.WriteByte('\n')
+	return append(dst, e.Bytes()...), err
+}
+
+// appendTextFields appends fields in the form of key=value, separated by spaces.
+func (e *encodeState) appendTextFields(fields []KV) error {
+	var errs []error
+	for _, kv := range fields {
+		e.WriteByte(' ')
+		e.appendTextString(kv.Key)
+		e.WriteByte('=')
+		if s, ok := kv.Value.(string); ok {
+			if _, ok := lookupKeyEncoder(kv.Key); !ok {
+				e.appendTextString(s)
+				continue
+			}
+		}
+		if err := e.appendFieldOr(kv.Key, kv.Value, encodeErrorPlaceholder); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// appendTextString appends s as is if it is safe in the text format.
+// Otherwise, it appends s as a quoted JSON string.
+func (e *encodeState) appendTextString(s string) {
+	if needsQuote(s) {
+		e.appendString(s)
+	} else {
+		e.WriteString(s)
+	}
+}
+
+// appendTextMessage appends s as is unless it has control characters,
+// which may forge another line or the escape sequences of terminals.
+// Otherwise, it appends s as a quoted JSON string.
+func (e *encodeState) appendTextMessage(s string) {
+	if hasControl(s) {
+		e.appendString(s)
+	} else {
+		e.WriteString(s)
+	}
+}
+
+func hasControl(s string) bool {
+	for _, r := range s {
+		if r < ' ' || (r >= '\u007f' && r <= '\u009f') || r == '\u2028' || r == '\u2029' || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\u007f' || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,302 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTextFormatter(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC),
+		Level:   LevelInfo,
+		Message: "hello world",
+		Flags:   Ldate | Ltime | LUTC,
+		Fields: []KV{
+			{Key: "number", Value: 42},
+			{Key: "quoted", Value: "foo bar"},
+			{Key: "string", Value: "foobar"},
+		},
+	}
+
+	f := &TextFormatter{}
+	got, err := f.Format(nil, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2001-02-03T04:05:06Z INFO hello world number=42 quoted=\"foo bar\" string=foobar\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", string(got), want)
+	}
+}
+
+func TestTextFormatter_ControlCharacters(t *testing.T) {
+	tests := []struct {
+		message string
+		file    string
+		want    string
+	}{
+		{
+			message: "a\n2001-02-03T04:05:06Z INFO forged",
+			file:    "main.go",
+			want:    "INFO main.go:42 \"a\\n2001-02-03T04:05:06Z INFO forged\"\n",
+		},
+		{
+			message: "\x1b[2Jcleared",
+			file:    "main.go",
+			want:    "INFO main.go:42 \"\\u001b[2Jcleared\"\n",
+		},
+		{
+			message: "hello world",
+			file:    "a\nb.go",
+			want:    "INFO \"a\\nb.go\":42 hello world\n",
+		},
+	}
+	for _, tt := range tests {
+		entry := &Entry{
+			Level:   LevelInfo,
+			Message: tt.message,
+			File:    tt.file,
+			Line:    42,
+			Flags:   Lshortfile,
+		}
+		got, err := (&TextFormatter{}).Format(nil, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("got %q, want %q", string(got), tt.want)
+		}
+	}
+}
+
+func TestTextFormatter_EnableColor(t *testing.T) {
+	entry := &Entry{
+		Level:   LevelError,
+		Message: "hello world",
+	}
+
+	f := &TextFormatter{EnableColor: true}
+	got, err := f.Format(nil, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b[31mERROR\x1b[0m hello world\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", string(got), want)
+	}
+}
+
+func TestTextFormatter_ColorOnlyLevel(t *testing.T) {
+	levels := []Level{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal, LevelPanic}
+	for _, level := range levels {
+		entry := &Entry{
+			Level:   level,
+			Message: "hello world",
+			Flags:   Lshortfile,
+			File:    "main.go",
+			Line:    42,
+			Fields:  []KV{{Key: "key", Value: "value"}},
+		}
+		plain, err := (&TextFormatter{}).Format(nil, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		colored, err := (&TextFormatter{EnableColor: true}).Format(nil, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// removing the escape sequences around the level restores the uncolored line.
+		name := levelUpper(level)
+		stripped := bytes.Replace(colored, []byte(levelColor(level)+name+colorReset), []byte(name), 1)
+		if !bytes.Equal(stripped, plain) {
+			t.Errorf("%s: got %q, want %q", level, colored, plain)
+		}
+	}
+}
+
+func TestNewTextFormatter(t *testing.T) {
+	if f := NewTextFormatter(new(bytes.Buffer)); f.EnableColor {
+		t.Error("want color disabled for a non-terminal writer")
+	}
+}
+
+func TestSetColorLevels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetFormatter(&TextFormatter{EnableColor: true})
+	l.SetLevel(LevelDebug)
+	l.SetColorLevels(LevelWarn)
+
+	l.Debug(context.Background(), "debug", nil)
+	l.Warn(context.Background(), "warn", nil)
+	want := "DEBUG debug\n" + "\x1b[33mWARN\x1b[0m warn\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestJSONFormatter_TimeFormatter(t *testing.T) {
+	now := time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC)
+	tests := []struct {
+		format TimeFormatter
+		want   string
+	}{
+		{
+			format: nil,
+			want:   `{"time":"2001-02-03T04:05:06.123456Z","level":"info","message":"hello"}` + "\n",
+		},
+		{
+			format: TimeRFC3339Nano,
+			want:   `{"time":"2001-02-03T04:05:06.123456789Z","level":"info","message":"hello"}` + "\n",
+		},
+		{
+			format: TimeUnixMillis,
+			want:   `{"time":981173106123,"level":"info","message":"hello"}` + "\n",
+		},
+	}
+
+	f := &JSONFormatter{}
+	for _, tt := range tests {
+		entry := &Entry{
+			Time:       now,
+			Level:      LevelInfo,
+			Message:    "hello",
+			Flags:      LstdFlags | LUTC,
+			timeFormat: tt.format,
+		}
+		got, err := f.Format(nil, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("got %q, want %q", string(got), tt.want)
+		}
+	}
+}
+
+func TestSetTimeFormatter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", LstdFlags)
+	l.SetTimeFormatter(TimeUnixMillis)
+	l.Info(context.Background(), "hello", nil)
+
+	var got struct {
+		Time json.Number `json:"time"`
+	}
+	dec := json.NewDecoder(buf)
+	dec.UseNumber()
+	if err := dec.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := got.Time.Int64(); err != nil {
+		t.Errorf("the time is not an integer: %v", err)
+	}
+}
+
+func TestSetFormatter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetFormatter(&TextFormatter{})
+	l.Info(context.Background(), "hello", Fields{"message": "reserved"})
+
+	want := "INFO hello field.message=reserved\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestJSONFormatter_Fragment(t *testing.T) {
+	entry := &Entry{
+		Level:   LevelInfo,
+		Message: "hello",
+		Fields: []KV{
+			{Key: "key", Value: "value"},
+		},
+	}
+
+	f := &JSONFormatter{Fragment: true}
+	got, err := f.Format(nil, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"level":"info","message":"hello","key":"value"` + "\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", string(got), want)
+	}
+}
+
+func TestSetRootKey(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetRootKey("app")
+
+	l.Info(context.Background(), "hello", Fields{"key": "value", "number": 42})
+	l.Info(context.Background(), "no fields", nil)
+
+	want := `{"level":"info","message":"hello","app":{"key":"value","number":42}}` + "\n" +
+		`{"level":"info","message":"no fields"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatterParity(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		flag     int
+		fields   Fields
+		wantJSON string
+		wantText string
+	}{
+		{
+			name:     "reserved keys",
+			fields:   Fields{"time": 1, "level": 2, "file": 3, "line": 4, "message": 5},
+			wantJSON: `{"level":"info","message":"hello","field.file":3,"field.level":2,"field.line":4,"field.message":5,"field.time":1}` + "\n",
+			wantText: "INFO hello field.file=3 field.level=2 field.line=4 field.message=5 field.time=1\n",
+		},
+		{
+			name:     "prefix",
+			prefix:   "[app] ",
+			wantJSON: `{"level":"info","message":"[app] hello"}` + "\n",
+			wantText: "INFO [app] hello\n",
+		},
+		{
+			name:     "msgprefix",
+			prefix:   " [app]",
+			flag:     Lmsgprefix,
+			wantJSON: `{"level":"info","message":"hello [app]"}` + "\n",
+			wantText: "INFO hello [app]\n",
+		},
+		{
+			name:     "sorted fields",
+			fields:   Fields{"b": 2, "c": 3, "a": 1},
+			wantJSON: `{"level":"info","message":"hello","a":1,"b":2,"c":3}` + "\n",
+			wantText: "INFO hello a=1 b=2 c=3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			l := New(buf, "", tt.flag)
+			l.SetPrefix(tt.prefix)
+
+			l.Info(context.Background(), "hello", tt.fields)
+			if got := buf.String(); got != tt.wantJSON {
+				t.Errorf("json: got %q, want %q", got, tt.wantJSON)
+			}
+
+			buf.Reset()
+			l.SetFormatter(&TextFormatter{})
+			l.Info(context.Background(), "hello", tt.fields)
+			if got := buf.String(); got != tt.wantText {
+				t.Errorf("text: got %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package ctxlog
 
 import (
+	"bytes"
 	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func FuzzString(f *testing.F) {
@@ -25,6 +27,52 @@ func FuzzString(f *testing.F) {
 	})
 }
 
+func FuzzTime(f *testing.F) {
+	f.Add(int64(0), int64(0))
+	f.Add(int64(981173106), int64(123456789))
+	f.Add(int64(-62135596800), int64(999999999))
+
+	f.Fuzz(func(t *testing.T, sec, nsec int64) {
+		for _, v := range []any{time.Unix(sec, nsec), time.Duration(sec)} {
+			e := newEncodeState()
+			if err := e.appendAny(v); err != nil {
+				t.Fatal(err)
+			}
+
+			data := e.Bytes()
+			if !json.Valid(data) {
+				t.Errorf("invalid json: %q", string(data))
+			}
+		}
+	})
+}
+
+func FuzzMarshaler(f *testing.F) {
+	f.Add(`"custom"`)
+	f.Add("{\n  \"color\": \"<red>\"\n}\n")
+	f.Add(`[1, 2, 3]`)
+	f.Add(`{"unterminated":`)
+	f.Add("\u2028")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		e := newEncodeState()
+		e.WriteString(`{"message":""`)
+		e.writeFields([]KV{
+			{Key: "json", Value: testJSONMarshaler(s)},
+			{Key: "text", Value: testTextMarshaler(s)},
+		})
+		e.WriteByte('}')
+
+		data := e.Bytes()
+		if !json.Valid(data) {
+			t.Errorf("invalid json: %q", string(data))
+		}
+		if bytes.ContainsRune(data, '\n') {
+			t.Errorf("unexpected newline: %q", string(data))
+		}
+	})
+}
+
 func FuzzTinyJSON(f *testing.F) {
 	f.Add(`{}`, `{}`)
 	f.Add(`{"foo":"bar"}`, `{"hoge":"fuga"}`)
@@ -36,6 +84,7 @@ func FuzzTinyJSON(f *testing.F) {
 	f.Add(`{"foo":"bar"}`, `{"hoge":false}`)
 	f.Add(`{"foo":"[2,3,4]]"}`, `{"foo":[1,2,3]}`)
 	f.Add(`{"foo":"[2,3,4]]"}`, `{"foo":{"bar":"fiuzz"}}}`)
+	f.Add(`{"foo":{"z":1,"a":{"b":[1,{"c":"<d>"}]}}}`, `{"bar":{}}`)
 
 	f.Fuzz(func(t *testing.T, raw0, raw1 string) {
 		var parent, child map[string]any
@@ -72,3 +121,27 @@ func FuzzTinyJSON(f *testing.F) {
 		}
 	})
 }
+
+func FuzzFieldKey(f *testing.F) {
+	f.Add("foo", "bar")
+	f.Add("time", "message")
+	f.Add("field.time", "time")
+	f.Add("\n", "\"")
+	f.Add("<script>", " ")
+	f.Add("\x00", "\x80")
+
+	f.Fuzz(func(t *testing.T, key0, key1 string) {
+		e := newEncodeState()
+		e.WriteString(`{"message":""`)
+		parent := &mergedFields{fields: Fields{key0: "parent"}}
+		if err := e.appendFields(parent, Fields{key1: "child"}); err != nil {
+			t.Fatal(err)
+		}
+		e.WriteByte('}')
+
+		data := e.Bytes()
+		if !json.Valid(data) {
+			t.Errorf("invalid json: %q", string(data))
+		}
+	})
+}
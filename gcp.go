@@ -0,0 +1,86 @@
+package ctxlog
+
+import "time"
+
+// GCPFormatter formats entries in the structured logging format of Google Cloud Logging.
+// The reserved fields are mapped to the special keys that Cloud Logging recognizes:
+// "severity", "message", "timestamp", and "logging.googleapis.com/sourceLocation".
+//
+// See https://cloud.google.com/logging/docs/structured-logging
+type GCPFormatter struct{}
+
+var _ Formatter = (*GCPFormatter)(nil)
+
+var gcpReserved = []string{
+	"message",
+	"severity",
+	"timestamp",
+	"logging.googleapis.com/sourceLocation",
+}
+
+func gcpSeverity(level Level) string {
+	switch level {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARNING"
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "CRITICAL"
+	case LevelPanic:
+		return "ALERT"
+	case LevelNo, LevelDisabled:
+		return "DEFAULT"
+	}
+	return "DEBUG"
+}
+
+// Format implements Formatter.
+func (f *GCPFormatter) Format(dst []byte, entry *Entry) ([]byte, error) {
+	e := encodeStatePool.Get().(*encodeState)
+	defer encodeStatePool.Put(e)
+	e.resetEntry(entry)
+
+	e.WriteByte('{')
+
+	e.appendString("severity")
+	e.WriteByte(':')
+	e.appendString(gcpSeverity(entry.Level))
+	e.WriteByte(',')
+
+	e.appendString("message")
+	e.WriteByte(':')
+	e.appendString(entry.Message)
+
+	if entry.Flags&(Ldate|Ltime|Lmicroseconds) != 0 {
+		e.WriteByte(',')
+		e.appendString("timestamp")
+		e.WriteByte(':')
+		e.appendTimeLayout(time.RFC3339Nano, entry.Time.UTC())
+	}
+
+	if entry.Flags&(Lshortfile|Llongfile) != 0 {
+		e.WriteByte(',')
+		e.appendString("logging.googleapis.com/sourceLocation")
+		e.WriteString(":{")
+		e.appendString("file")
+		e.WriteByte(':')
+		e.appendString(entry.File)
+		e.WriteByte(',')
+		e.appendString("line")
+		e.WriteByte(':')
+		e.WriteByte('"')
+		e.appendInt(int64(entry.Line))
+		e.WriteByte('"')
+		e.WriteByte('}')
+	}
+
+	err := e.writeFields(entry.Fields, gcpReserved...)
+
+	e.WriteByte('}')
+	e.WriteByte('\n')
+	return append(dst, e.Bytes()...), err
+}
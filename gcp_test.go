@@ -0,0 +1,33 @@
+package ctxlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGCPFormatter(t *testing.T) {
+	entry := &Entry{
+		Time:    time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.FixedZone("Asia/Tokyo", 9*60*60)),
+		Level:   LevelWarn,
+		Message: "hello",
+		File:    "main.go",
+		Line:    42,
+		Flags:   LstdFlags | Lshortfile,
+		Fields: []KV{
+			{Key: "severity", Value: "user"},
+			{Key: "user", Value: "alice"},
+		},
+	}
+
+	f := &GCPFormatter{}
+	got, err := f.Format(nil, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"severity":"WARNING","message":"hello","timestamp":"2001-02-02T19:05:06.123456789Z",` +
+		`"logging.googleapis.com/sourceLocation":{"file":"main.go","line":"42"},` +
+		`"field.severity":"user","user":"alice"}` + "\n"
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+package ctxlog
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// The goroutine-local fields are keyed by the goroutine id.
+// goroutineBound counts the goroutines that have fields,
+// so that the logger doesn't look up the goroutine id if no one uses this feature.
+var (
+	goroutineFields sync.Map // map[uint64]*mergedFields
+	goroutineBound  atomic.Int64
+)
+
+// BindGoroutine attaches fields to the current goroutine,
+// and returns a function that detaches them.
+// The fields are emitted by every entry logged from the goroutine, even without a context.
+// They have lower precedence than the fields attached to the context.
+//
+// It is intended for legacy code that can't pass a context.Context around.
+// Prefer With where possible, and be aware of the caveats:
+//
+//   - goroutines started from the goroutine don't inherit the fields.
+//   - the returned function must be called on the same goroutine, typically with defer.
+//   - looking up the current goroutine makes logging slower while any goroutine has fields.
+func BindGoroutine(fields Fields) (unbind func()) {
+	id := goroutineID()
+	var parent *mergedFields
+	if v, ok := goroutineFields.Load(id); ok {
+		parent = v.(*mergedFields)
+	} else {
+		goroutineBound.Add(1)
+	}
+	goroutineFields.Store(id, &mergedFields{
+		parent: parent,
+		fields: fields,
+	})
+
+	return func() {
+		if parent != nil {
+			goroutineFields.Store(id, parent)
+			return
+		}
+		goroutineFields.Delete(id)
+		goroutineBound.Add(-1)
+	}
+}
+
+// GoWithContext runs fn in a new goroutine with a context that carries
+// the ctxlog values of ctx: the fields attached by With, the tags, the timezone,
+// the logger attached by NewContext and the values of the keys registered by RegisterContextKey.
+//
+// The context is detached from ctx deliberately.
+// It derives from context.Background, so it is never canceled
+// and has no deadline, even if ctx is canceled after fn starts.
+// Use it for the workers that outlive the request but should keep its correlation fields.
+func GoWithContext(ctx context.Context, fn func(context.Context)) {
+	detached := detachContext(ctx)
+	go fn(detached)
+}
+
+// detachContext returns a background context that carries the ctxlog values of ctx.
+func detachContext(ctx context.Context) context.Context {
+	detached := context.Background()
+	if f := contextFields(ctx); f != nil {
+		detached = context.WithValue(detached, keyFields, f)
+	}
+	if t := contextTags(ctx); t != nil {
+		detached = context.WithValue(detached, keyTags, t)
+	}
+	if loc := contextTimezone(ctx); loc != nil {
+		detached = context.WithValue(detached, keyTimezone, loc)
+	}
+	if n := contextAttempt(ctx); n != 0 {
+		detached = context.WithValue(detached, keyAttempt, n)
+	}
+	if l, ok := ctx.Value(keyLogger).(*Logger); ok && l != nil {
+		detached = context.WithValue(detached, keyLogger, l)
+	}
+	if keys := contextKeys.Load(); keys != nil {
+		for _, k := range *keys {
+			if v := ctx.Value(k.key); v != nil {
+				detached = context.WithValue(detached, k.key, v)
+			}
+		}
+	}
+	return detached
+}
+
+// currentGoroutineFields returns the fields attached to the current goroutine.
+func currentGoroutineFields() *mergedFields {
+	if goroutineBound.Load() == 0 {
+		return nil
+	}
+	v, ok := goroutineFields.Load(goroutineID())
+	if !ok {
+		return nil
+	}
+	return v.(*mergedFields)
+}
+
+// goroutineID returns the id of the current goroutine.
+// It parses the header of the stack trace: "goroutine 123 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	const prefix = "goroutine "
+	if len(b) < len(prefix) {
+		return 0
+	}
+	b = b[len(prefix):]
+	i := 0
+	for i < len(b) && b[i] >= '0' && b[i] <= '9' {
+		i++
+	}
+	id, err := strconv.ParseUint(string(b[:i]), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,103 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestBindGoroutine(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+
+	unbind := BindGoroutine(Fields{"request_id": "req-1", "user": "alice"})
+	unbind2 := BindGoroutine(Fields{"user": "bob"})
+	l.Print("hello")
+
+	var got struct {
+		RequestID string `json:"request_id"`
+		User      string
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.RequestID != "req-1" {
+		t.Errorf("unexpected request_id: got %q, want %q", got.RequestID, "req-1")
+	}
+	if got.User != "bob" {
+		t.Errorf("unexpected user: got %q, want %q", got.User, "bob")
+	}
+
+	// other goroutines don't see the fields.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if f := currentGoroutineFields(); f != nil {
+			t.Errorf("want nil, got %v", f.fields)
+		}
+	}()
+	<-done
+
+	unbind2()
+	if f := currentGoroutineFields(); f == nil || f.fields["user"] != "alice" {
+		t.Error("the outer fields are not restored")
+	}
+	unbind()
+	if f := currentGoroutineFields(); f != nil {
+		t.Errorf("want nil, got %v", f.fields)
+	}
+}
+
+func TestGoWithContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = With(ctx, Fields{"request_id": "req-1"})
+	ctx = WithTags(ctx, "worker")
+
+	done := make(chan struct{})
+	GoWithContext(ctx, func(ctx context.Context) {
+		defer close(done)
+		cancel()
+		if err := ctx.Err(); err != nil {
+			t.Errorf("the context is canceled: %v", err)
+		}
+		l.Info(ctx, "hello", nil)
+	})
+	<-done
+
+	var got struct {
+		RequestID string `json:"request_id"`
+		Tags      []string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.RequestID != "req-1" {
+		t.Errorf("unexpected request_id: got %q, want %q", got.RequestID, "req-1")
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != "worker" {
+		t.Errorf("unexpected tags: got %v, want [worker]", got.Tags)
+	}
+}
+
+func TestGoWithContext_Logger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	ctx := NewContext(context.Background(), l)
+
+	done := make(chan struct{})
+	GoWithContext(ctx, func(ctx context.Context) {
+		defer close(done)
+		FromContext(ctx).Info(ctx, "hello", nil)
+	})
+	<-done
+
+	want := `{"level":"info","message":"hello"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package ctxlog
+
+import (
+	"sort"
+	"time"
+)
+
+// AddHook registers fn to rewrite the fields of every entry before encoding,
+// e.g. for adding the fields or dropping the sensitive fields regardless of where they came from:
+//
+//	logger.AddHook(func(level ctxlog.Level, fields map[string]any) {
+//		delete(fields, "password")
+//	})
+//
+// The hooks run in the order of the registration, after the fields are merged and before they are encoded.
+// fields has all the merged fields, and the reserved fields:
+// the time as time.Time, the level as Level and the message as string,
+// under the keys configured by SetFieldNames.
+// The hooks may add, rewrite and delete the keys.
+// The reserved fields are updated by the values of the same types,
+// and keep their values if they are deleted.
+//
+// The hooks are called without holding any lock of the logger, so they may run concurrently.
+// They are called for every entry, so they must be cheap.
+// The loggers derived by With copy the hooks registered at the time.
+func (l *Logger) AddHook(fn func(level Level, fields map[string]any)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// copy on write, because the hooks are running without the lock.
+	l.hooks = append(l.hooks[:len(l.hooks):len(l.hooks)], fn)
+}
+
+// AddHook registers fn to rewrite the fields of every entry of the standard logger.
+func AddHook(fn func(level Level, fields map[string]any)) {
+	std.AddHook(fn)
+}
+
+// Hooks returns the hooks registered by AddHook.
+func (l *Logger) Hooks() []func(level Level, fields map[string]any) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.hooks[:len(l.hooks):len(l.hooks)]
+}
+
+// runHooks runs hooks on the reserved fields and the fields of entry,
+// and then updates entry with the result.
+// If insertion is true, the fields keep their order, and the fields added by the hooks follow them in key order.
+// Otherwise, the fields are sorted by key.
+func runHooks(hooks []func(Level, map[string]any), entry *Entry, insertion bool) {
+	names := entry.names.withDefaults()
+	fields := make(map[string]any, len(entry.Fields)+3)
+	for _, f := range entry.Fields {
+		fields[f.Key] = f.Value
+	}
+	fields[names.Time] = entry.Time
+	fields[names.Level] = entry.Level
+	fields[names.Message] = entry.Message
+
+	for _, fn := range hooks {
+		fn(entry.Level, fields)
+	}
+
+	if t, ok := fields[names.Time].(time.Time); ok {
+		entry.Time = t
+	}
+	if level, ok := fields[names.Level].(Level); ok {
+		entry.Level = level
+	}
+	if msg, ok := fields[names.Message].(string); ok {
+		entry.Message = msg
+	}
+	delete(fields, names.Time)
+	delete(fields, names.Level)
+	delete(fields, names.Message)
+
+	kv := make([]KV, 0, len(fields))
+	if insertion {
+		for _, f := range entry.Fields {
+			if v, ok := fields[f.Key]; ok {
+				kv = append(kv, KV{Key: reservedKey(f.Key, &names), Value: v})
+				delete(fields, f.Key)
+			}
+		}
+	}
+	n := len(kv)
+	for k, v := range fields {
+		kv = append(kv, KV{Key: reservedKey(k, &names), Value: v})
+	}
+	sort.Sort(keyValues(kv[n:]))
+	entry.Fields = kv
+}
+
+// AddProcessor registers fn to process every entry before encoding,
+// e.g. for enriching the entries or dropping the noisy ones:
+//
+//	logger.AddProcessor(func(e *ctxlog.Entry) bool {
+//		return e.Message != "health check"
+//	})
+//
+// fn returns false to drop the entry, and then the rest of the processors are skipped.
+// The audit entries logged by Audit are never dropped.
+// The processors run in the order of the registration, after the hooks registered by AddHook
+// and before the fields are redacted, so the fields added by them are redacted as well.
+// They may rewrite the time, the level, the message and the fields of e.
+// The fields should be kept in the order of the logger, see SetFieldOrder.
+// e is reused after the processors return, so they must not retain it.
+//
+// The processors are called without holding any lock of the logger, so they may run concurrently.
+// The loggers derived by With copy the processors registered at the time.
+func (l *Logger) AddProcessor(fn func(e *Entry) bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// copy on write, because the processors are running without the lock.
+	l.processors = append(l.processors[:len(l.processors):len(l.processors)], fn)
+}
+
+// AddProcessor registers fn to process every entry of the standard logger.
+func AddProcessor(fn func(e *Entry) bool) {
+	std.AddProcessor(fn)
+}
+
+// Processors returns the processors registered by AddProcessor.
+func (l *Logger) Processors() []func(e *Entry) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.processors[:len(l.processors):len(l.processors)]
+}
+
+// runProcessors runs processors on entry.
+// It reports whether the entry is kept.
+// If force is true, the entry is kept and all the processors run regardless of their results.
+func runProcessors(processors []func(*Entry) bool, entry *Entry, force bool) bool {
+	for _, fn := range processors {
+		if !fn(entry) && !force {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAddHook(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.AddHook(func(level Level, fields map[string]any) {
+		fields["hostname"] = "example"
+		delete(fields, "password")
+	})
+	l.AddHook(func(level Level, fields map[string]any) {
+		// the hooks run in the order of the registration.
+		if _, ok := fields["hostname"]; !ok {
+			t.Error("want hostname added by the first hook")
+		}
+		if level >= LevelError {
+			fields["message"] = strings.ToUpper(fields["message"].(string))
+			fields["level"] = LevelFatal
+		}
+		delete(fields, "time")
+	})
+
+	ctx := With(context.Background(), Fields{"password": "secret"})
+	l.Info(ctx, "login", Fields{"user": "alice"})
+	l.Error(context.Background(), "failed", nil)
+
+	want := `{"level":"info","message":"login","hostname":"example","user":"alice"}` + "\n" +
+		`{"level":"fatal","message":"FAILED","hostname":"example"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestAddHook_Race(t *testing.T) {
+	l := New(discard, "", 0)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			l.AddHook(func(level Level, fields map[string]any) {
+				fields["hooked"] = true
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Info(ctx, "hello", Fields{"n": j})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAddProcessor(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetRedactKeys("token")
+	l.AddProcessor(func(e *Entry) bool {
+		return e.Message != "health check"
+	})
+	l.AddProcessor(func(e *Entry) bool {
+		// the fields added by the processors are redacted.
+		e.Fields = append(e.Fields, KV{Key: "token", Value: "secret"})
+		if e.Level == LevelWarn {
+			e.Level = LevelError
+		}
+		return true
+	})
+	child := l.With(Fields{"component": "test"})
+
+	ctx := context.Background()
+	child.Info(ctx, "health check", nil)
+	child.Warn(ctx, "slow", Fields{"user": "alice"})
+
+	want := `{"level":"error","message":"slow","component":"test","user":"alice","token":"[REDACTED]"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestAddProcessor_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops the items randomly under the race detector")
+	}
+	l := New(discard, "", 0)
+	l.AddProcessor(func(e *Entry) bool {
+		return e.Level >= LevelInfo
+	})
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Info(ctx, "test", nil)
+	})
+	if allocs != 0 {
+		t.Errorf("unexpected allocations: got %f, want 0", allocs)
+	}
+}
@@ -0,0 +1,75 @@
+package ctxlog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// KeyEncoder appends the JSON encoding of v to dst and returns the extended buffer.
+type KeyEncoder func(dst []byte, v any) ([]byte, error)
+
+var (
+	keyEncodersMu sync.Mutex
+	keyEncoders   atomic.Pointer[map[string]KeyEncoder]
+)
+
+// RegisterKeyEncoder registers fn to encode the values of the fields named key,
+// regardless of their Go types.
+// It is consulted before the encoders for the types,
+// e.g. it can render a time.Time under "born" as a date:
+//
+//	ctxlog.RegisterKeyEncoder("born", func(dst []byte, v any) ([]byte, error) {
+//		t, ok := v.(time.Time)
+//		if !ok {
+//			return nil, errors.New("born must be time.Time")
+//		}
+//		return t.AppendFormat(append(dst, '"'), "2006-01-02"), nil
+//	})
+//
+// fn must append valid JSON.
+// If fn is nil, the encoder of key is unregistered.
+func RegisterKeyEncoder(key string, fn KeyEncoder) {
+	keyEncodersMu.Lock()
+	defer keyEncodersMu.Unlock()
+
+	encoders := map[string]KeyEncoder{}
+	if old := keyEncoders.Load(); old != nil {
+		for k, v := range *old {
+			encoders[k] = v
+		}
+	}
+	if fn == nil {
+		delete(encoders, key)
+	} else {
+		encoders[key] = fn
+	}
+	if len(encoders) == 0 {
+		keyEncoders.Store(nil)
+		return
+	}
+	keyEncoders.Store(&encoders)
+}
+
+// lookupKeyEncoder returns the encoder registered for key.
+func lookupKeyEncoder(key string) (KeyEncoder, bool) {
+	encoders := keyEncoders.Load()
+	if encoders == nil {
+		return nil, false
+	}
+	fn, ok := (*encoders)[key]
+	return fn, ok
+}
+
+// appendField appends the value of the field key.
+// It uses the encoder registered by RegisterKeyEncoder if any.
+func (e *encodeState) appendField(key string, v any) error {
+	if fn, ok := lookupKeyEncoder(key); ok {
+		b, err := fn(e.scratch[:0], v)
+		if err != nil {
+			return err
+		}
+		e.Write(b)
+		return nil
+	}
+	return e.appendAny(v)
+}
@@ -0,0 +1,41 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegisterKeyEncoder(t *testing.T) {
+	RegisterKeyEncoder("born", func(dst []byte, v any) ([]byte, error) {
+		t, ok := v.(time.Time)
+		if !ok {
+			return nil, errors.New("born must be time.Time")
+		}
+		dst = append(dst, '"')
+		dst = t.AppendFormat(dst, "2006-01-02")
+		return append(dst, '"'), nil
+	})
+	defer RegisterKeyEncoder("born", nil)
+
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetTimeFieldLayout(TimeFieldSeconds)
+	born := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	l.Info(context.Background(), "hello", Fields{"born": born, "ts": born})
+
+	want := `{"level":"info","message":"hello","born":"2001-02-03","ts":"2001-02-03T04:05:06Z"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, ok := lookupKeyEncoder("born"); !ok {
+		t.Error("the encoder is not registered")
+	}
+	RegisterKeyEncoder("born", nil)
+	if keyEncoders.Load() != nil {
+		t.Error("the encoder is not unregistered")
+	}
+}
@@ -0,0 +1,101 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Level
+	}{
+		{"trace", LevelTrace},
+		{"debug", LevelDebug},
+		{"INFO", LevelInfo},
+		{"Warn", LevelWarn},
+		{"error", LevelError},
+		{"fatal", LevelFatal},
+		{"panic", LevelPanic},
+		{"no", LevelNo},
+		{"disabled", LevelDisabled},
+		{"-2", Level(-2)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseLevel("unknown"); err == nil {
+		t.Error("want error, but got nil")
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	var level Level
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&level, "log-level", "log level")
+	if err := fs.Parse([]string{"-log-level", "warn"}); err != nil {
+		t.Fatal(err)
+	}
+	if level != LevelWarn {
+		t.Errorf("got %v, want %v", level, LevelWarn)
+	}
+}
+
+func TestSetLevelFormat(t *testing.T) {
+	tests := []struct {
+		format LevelFormat
+		want   string
+	}{
+		{LevelFull, `{"level":"info","message":"hello"}` + "\n"},
+		{LevelShort, `{"level":"I","message":"hello"}` + "\n"},
+		{LevelUpper, `{"level":"INFO","message":"hello"}` + "\n"},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		l := New(buf, "", 0)
+		l.SetLevelFormat(tt.format)
+		l.Info(context.Background(), "hello", nil)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("format %d: got %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	type config struct {
+		Level Level `json:"level"`
+	}
+	for _, level := range []Level{Level(-2), LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal, LevelPanic, LevelNo, LevelDisabled} {
+		data, err := json.Marshal(config{Level: level})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got config
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Level != level {
+			t.Errorf("%s: got %v, want %v", data, got.Level, level)
+		}
+	}
+
+	var got config
+	if err := json.Unmarshal([]byte(`{"level":"verbose"}`), &got); err == nil {
+		t.Error("want error, but got nil")
+	}
+	if err := json.Unmarshal([]byte(`{"level":"WARN"}`), &got); err != nil || got.Level != LevelWarn {
+		t.Errorf("got %v, %v, want %v", got.Level, err, LevelWarn)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 )
 
 // compatible layer for the log package
@@ -17,6 +16,15 @@ const (
 	Lshortfile                                    // final file name element and line number: d.go:23. overrides Llongfile
 	LUTC                                          // if Ldate or Ltime is set, use UTC rather than the local time zone
 	Lmsgprefix                                    // move the "prefix" from the beginning of the line to before the message
+	Lbuildinfo                                    // the vcs revision and time of the build: "commit" and "build_time" fields
+	Lunixmilli                                    // the time in milliseconds since the Unix epoch: "ts_unix" field
+	Lbudget                                       // the remaining timeout budget in percent, see WithBudget: "budget_pct" field
+	Lfingerprint                                  // the hash of the message and the fields, see SetFingerprintFields: "fingerprint" field
+	Luptime                                       // the milliseconds since the process started on the monotonic clock: "uptime_ms" field
+	Lprocid                                       // the id of the processor (P) that runs the goroutine, needs the ctxlog_procid build tag: "procid" field
+	Ldonereason                                   // why the context is done, "timeout" or "canceled": "done_reason" field
+	Lerrorstack                                   // the stack traces carried by the errors in the fields: "<key>.stack" fields
+	Lfuncname                                     // the fully qualified function name of the caller: "func" field
 	LstdFlags     = Ldate | Ltime | Lmicroseconds // initial values for the standard logger
 )
 
@@ -27,7 +35,7 @@ func (l *Logger) Output(calldepth int, s string) error {
 // Print calls l.OutputContext to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Print(v ...any) {
-	if l.isDiscard.Load() {
+	if l.discarding() {
 		return
 	}
 	l.OutputContext(context.Background(), 2, LevelNo, fmt.Sprint(v...), nil)
@@ -36,7 +44,7 @@ func (l *Logger) Print(v ...any) {
 // Printf calls l.OutputContext to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Printf(format string, v ...any) {
-	if l.isDiscard.Load() {
+	if l.discarding() {
 		return
 	}
 	l.OutputContext(context.Background(), 2, LevelNo, fmt.Sprintf(format, v...), nil)
@@ -45,37 +53,37 @@ func (l *Logger) Printf(format string, v ...any) {
 // Println calls l.OutputContext to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Println(v ...any) {
-	if l.isDiscard.Load() {
+	if l.discarding() {
 		return
 	}
 	l.OutputContext(context.Background(), 2, LevelNo, fmt.Sprint(v...), nil)
 }
 
-// Fatal is equivalent to l.Print() followed by a call to os.Exit(1).
+// Fatal is equivalent to l.Print() followed by a call to os.Exit(l.FatalExitCode()).
 func (l *Logger) Fatal(v ...any) {
-	if l.isDiscard.Load() {
+	if l.discarding() {
 		return
 	}
 	l.OutputContext(context.Background(), 2, LevelFatal, fmt.Sprint(v...), nil)
-	os.Exit(1)
+	l.exit(l.FatalExitCode())
 }
 
-// Fatalf is equivalent to l.Printf() followed by a call to os.Exit(1).
+// Fatalf is equivalent to l.Printf() followed by a call to os.Exit(l.FatalExitCode()).
 func (l *Logger) Fatalf(format string, v ...any) {
-	if l.isDiscard.Load() {
+	if l.discarding() {
 		return
 	}
 	l.OutputContext(context.Background(), 2, LevelFatal, fmt.Sprintf(format, v...), nil)
-	os.Exit(1)
+	l.exit(l.FatalExitCode())
 }
 
-// Fatalln is equivalent to l.Println() followed by a call to os.Exit(1).
+// Fatalln is equivalent to l.Println() followed by a call to os.Exit(l.FatalExitCode()).
 func (l *Logger) Fatalln(v ...any) {
-	if l.isDiscard.Load() {
+	if l.discarding() {
 		return
 	}
 	l.OutputContext(context.Background(), 2, LevelFatal, fmt.Sprint(v...), nil)
-	os.Exit(1)
+	l.exit(l.FatalExitCode())
 }
 
 // Panic is equivalent to l.Print() followed by a call to panic().
@@ -102,14 +110,18 @@ func (l *Logger) Panicln(v ...any) {
 func (l *Logger) Prefix() string {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
+	if l.parent != nil && !l.ownPrefix {
+		return l.parent.Prefix()
+	}
 	return l.prefix
 }
 
 // SetPrefix sets the output prefix for the logger.
 func (l *Logger) SetPrefix(prefix string) {
 	l.mu.Lock()
-	defer l.mu.RUnlock()
+	defer l.mu.Unlock()
 	l.prefix = prefix
+	l.ownPrefix = true
 }
 
 // Flags returns the output flags for the logger.
@@ -117,6 +129,9 @@ func (l *Logger) SetPrefix(prefix string) {
 func (l *Logger) Flags() int {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
+	if l.parent != nil && !l.ownFlag {
+		return l.parent.Flags()
+	}
 	return l.flag
 }
 
@@ -126,6 +141,7 @@ func (l *Logger) SetFlags(flag int) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.flag = flag
+	l.ownFlag = true
 }
 
 // Output writes the output for a logging event.
@@ -136,7 +152,7 @@ func Output(calldepth int, s string) error {
 // Print calls Output to print to the standard logger.
 // Arguments are handled in the manner of fmt.Print.
 func Print(v ...any) {
-	if std.isDiscard.Load() {
+	if std.discarding() {
 		return
 	}
 	std.OutputContext(context.Background(), 2, LevelNo, fmt.Sprint(v...), nil)
@@ -145,7 +161,7 @@ func Print(v ...any) {
 // Printf calls Output to print to the standard logger.
 // Arguments are handled in the manner of fmt.Printf.
 func Printf(format string, v ...any) {
-	if std.isDiscard.Load() {
+	if std.discarding() {
 		return
 	}
 	std.OutputContext(context.Background(), 2, LevelNo, fmt.Sprintf(format, v...), nil)
@@ -154,28 +170,28 @@ func Printf(format string, v ...any) {
 // Println calls Output to print to the standard logger.
 // Arguments are handled in the manner of fmt.Println.
 func Println(v ...any) {
-	if std.isDiscard.Load() {
+	if std.discarding() {
 		return
 	}
 	std.OutputContext(context.Background(), 2, LevelNo, fmt.Sprintln(v...), nil)
 }
 
-// Fatal is equivalent to Print() followed by a call to os.Exit(1).
+// Fatal is equivalent to Print() followed by a call to os.Exit with the exit code set by SetFatalExitCode.
 func Fatal(v ...any) {
 	std.OutputContext(context.Background(), 2, LevelFatal, fmt.Sprint(v...), nil)
-	os.Exit(1)
+	std.exit(std.FatalExitCode())
 }
 
-// Fatalf is equivalent to Printf() followed by a call to os.Exit(1).
+// Fatalf is equivalent to Printf() followed by a call to os.Exit with the exit code set by SetFatalExitCode.
 func Fatalf(format string, v ...any) {
 	std.OutputContext(context.Background(), 2, LevelFatal, fmt.Sprintf(format, v...), nil)
-	os.Exit(1)
+	std.exit(std.FatalExitCode())
 }
 
-// Fatalln is equivalent to Println() followed by a call to os.Exit(1).
+// Fatalln is equivalent to Println() followed by a call to os.Exit with the exit code set by SetFatalExitCode.
 func Fatalln(v ...any) {
 	std.OutputContext(context.Background(), 2, LevelFatal, fmt.Sprint(v...), nil)
-	os.Exit(1)
+	std.exit(std.FatalExitCode())
 }
 
 // Panic is equivalent to Print() followed by a call to panic().
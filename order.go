@@ -0,0 +1,63 @@
+package ctxlog
+
+import "sync/atomic"
+
+var schema atomic.Pointer[[]string]
+
+// RegisterSchema registers the keys of the fields in the declared order of the schema.
+// The fields of the schema are emitted in the declared order,
+// followed by the undeclared fields in sorted order.
+// It aligns the log lines column-wise, and enables cheap prefix-based parsing
+// by the consumers who know the schema.
+// The priority fields of a logger, see Logger.SetPriorityFields, precede the fields of the schema.
+//
+// RegisterSchema replaces the schema registered before.
+// If keys is empty, the schema is unregistered.
+func RegisterSchema(keys ...string) {
+	if len(keys) == 0 {
+		schema.Store(nil)
+		return
+	}
+	keys = append([]string(nil), keys...)
+	schema.Store(&keys)
+}
+
+// PriorityFields returns the keys of the fields emitted before the other fields.
+// The returned slice must not be modified.
+func (l *Logger) PriorityFields() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.priority
+}
+
+// SetPriorityFields sets the keys of the fields emitted in the given order
+// right after the reserved fields such as "level" and "message".
+// The other fields are emitted in sorted order as usual.
+// It keeps the most-scanned correlation fields such as "trace_id" in a predictable position.
+func (l *Logger) SetPriorityFields(keys ...string) {
+	keys = append([]string(nil), keys...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.priority = keys
+}
+
+// orderFields moves the fields of keys to the front of fields in the order of keys.
+// The order of the other fields is preserved.
+// The keys in fields must be unique.
+func orderFields(fields []KV, keys []string) []KV {
+	n := 0
+	for _, key := range keys {
+		for i := n; i < len(fields); i++ {
+			if fields[i].Key != key {
+				continue
+			}
+			f := fields[i]
+			copy(fields[n+1:i+1], fields[n:i])
+			fields[n] = f
+			n++
+			break
+		}
+	}
+	return fields
+}
@@ -0,0 +1,45 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSetPriorityFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetPriorityFields("trace_id", "missing", "request_id")
+
+	l.Info(context.Background(), "hello", Fields{
+		"b":          2,
+		"request_id": "req-1",
+		"a":          1,
+		"trace_id":   "trace-1",
+	})
+	want := `{"level":"info","message":"hello","trace_id":"trace-1","request_id":"req-1","a":1,"b":2}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegisterSchema(t *testing.T) {
+	RegisterSchema("status", "method", "path")
+	defer RegisterSchema()
+
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetPriorityFields("request_id")
+
+	l.Info(context.Background(), "hello", Fields{
+		"path":       "/",
+		"extra":      true,
+		"status":     200,
+		"request_id": "req-1",
+		"method":     "GET",
+	})
+	want := `{"level":"info","message":"hello","request_id":"req-1","status":200,"method":"GET","path":"/","extra":true}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package ctxlog
+
+import (
+	"io"
+	"os"
+)
+
+// formattedOutput is a destination with its own formatter.
+type formattedOutput struct {
+	w         io.Writer
+	formatter Formatter
+}
+
+// multiOutput is the output of a logger configured by SetOutputs.
+type multiOutput struct {
+	outputs []formattedOutput
+}
+
+// Write writes p to all the outputs as is.
+func (m *multiOutput) Write(p []byte) (int, error) {
+	for _, o := range m.outputs {
+		if _, err := o.w.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush flushes the outputs that have a Flush method.
+func (m *multiOutput) Flush() error {
+	var err error
+	for _, o := range m.outputs {
+		if f, ok := o.w.(interface{ Flush() error }); ok {
+			if ferr := f.Flush(); err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+// Close closes the outputs that implement io.Closer, except os.Stdout and os.Stderr.
+func (m *multiOutput) Close() error {
+	var err error
+	for _, o := range m.outputs {
+		if o.w == os.Stdout || o.w == os.Stderr {
+			continue
+		}
+		if c, ok := o.w.(io.Closer); ok {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+// SetOutputs sets the output destinations of the logger.
+// The format is selected for each destination when SetOutputs is called:
+// terminals get human-readable text by TextFormatter, colored unless NO_COLOR is set,
+// and the others, such as files and network connections, get JSON by JSONFormatter.
+// The formatter of the logger set by SetFormatter is ignored.
+//
+//	f, _ := os.OpenFile("app.log", os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+//	logger.SetOutputs(os.Stderr, f)
+func (l *Logger) SetOutputs(ws ...io.Writer) {
+	m := &multiOutput{
+		outputs: make([]formattedOutput, 0, len(ws)),
+	}
+	for _, w := range ws {
+		var f Formatter = defaultFormatter
+		if isTerminal(w) {
+			f = NewTextFormatter(w)
+		}
+		m.outputs = append(m.outputs, formattedOutput{w: w, formatter: f})
+	}
+	l.SetOutput(m)
+}
+
+// SetOutputs sets the output destinations of the standard logger.
+// See Logger.SetOutputs for details.
+func SetOutputs(ws ...io.Writer) {
+	std.SetOutputs(ws...)
+}
+
+// writeOutputs formats entry for each output of m and writes it.
+func (l *Logger) writeOutputs(m *multiOutput, state *encodeState, entry *Entry) error {
+	var total int64
+	var firstErr error
+	for _, o := range m.outputs {
+		var err error
+		state.line, err = o.formatter.Format(state.line[:0], entry)
+		if err != nil {
+			l.handleError(err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			if len(state.line) == 0 {
+				continue
+			}
+		}
+
+		root := l.root()
+		root.mu.Lock()
+		n, err := writeLevel(o.w, entry.Level, state.line)
+		root.mu.Unlock()
+		total += int64(n)
+		if err != nil {
+			l.handleError(err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	l.stats.add(entry.Level, total)
+	return firstErr
+}
@@ -0,0 +1,34 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSetOutputs(t *testing.T) {
+	buf1 := new(bytes.Buffer)
+	buf2 := new(bytes.Buffer)
+	l := New(nil, "", 0)
+	l.SetFormatter(&TextFormatter{})
+	l.SetOutputs(buf1, buf2)
+
+	l.Info(context.Background(), "hello", Fields{"key": "value"})
+
+	// the writers that are not terminals get JSON.
+	want := `{"level":"info","message":"hello","key":"value"}` + "\n"
+	if got := buf1.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := buf2.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	stats := l.Stats().Levels[LevelInfo]
+	if stats.Lines != 1 {
+		t.Errorf("unexpected lines: got %d, want 1", stats.Lines)
+	}
+	if stats.Bytes != uint64(2*len(want)) {
+		t.Errorf("unexpected bytes: got %d, want %d", stats.Bytes, 2*len(want))
+	}
+}
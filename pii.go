@@ -0,0 +1,107 @@
+package ctxlog
+
+import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// The common patterns of PII, personally identifiable information, for AddPIIPattern.
+// They are not registered by default.
+var (
+	// PIIEmail matches email addresses.
+	PIIEmail = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// PIICreditCard matches the numbers of 13 to 19 digits, optionally separated by spaces or hyphens.
+	PIICreditCard = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+	// PIISSN matches the US social security numbers such as 123-45-6789.
+	PIISSN = regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)
+)
+
+type piiPattern struct {
+	name string
+	re   *regexp.Regexp
+	mask string
+}
+
+var (
+	piiPatternsMu sync.Mutex
+	piiPatterns   atomic.Pointer[[]piiPattern]
+)
+
+// AddPIIPattern registers re to mask PII, personally identifiable information, in the entries.
+// The matches in the message and the string values of the fields are replaced with "[REDACTED:name]",
+// and the number of the masked values is reported by Logger.Stats.
+// The values in nested objects, such as maps and structs, are not masked.
+//
+// Masking is opt-in because it runs every pattern on every entry.
+// AddPIIPattern replaces the pattern registered with the same name.
+// If re is nil, the pattern is unregistered.
+//
+//	ctxlog.AddPIIPattern("email", ctxlog.PIIEmail)
+func AddPIIPattern(name string, re *regexp.Regexp) {
+	piiPatternsMu.Lock()
+	defer piiPatternsMu.Unlock()
+
+	var patterns []piiPattern
+	if old := piiPatterns.Load(); old != nil {
+		for _, p := range *old {
+			if p.name != name {
+				patterns = append(patterns, p)
+			}
+		}
+	}
+	if re != nil {
+		patterns = append(patterns, piiPattern{
+			name: name,
+			re:   re,
+			mask: "[REDACTED:" + name + "]",
+		})
+	}
+	if len(patterns) == 0 {
+		piiPatterns.Store(nil)
+		return
+	}
+	piiPatterns.Store(&patterns)
+}
+
+// maskPII masks the message and the string fields of entry,
+// and returns the number of the masked values.
+func maskPII(entry *Entry) int {
+	patterns := piiPatterns.Load()
+	if patterns == nil {
+		return 0
+	}
+
+	var masked int
+	if s, ok := maskString(*patterns, entry.Message); ok {
+		entry.Message = s
+		masked++
+	}
+	for i, f := range entry.Fields {
+		v, ok := f.Value.(string)
+		if !ok {
+			continue
+		}
+		if s, ok := maskString(*patterns, v); ok {
+			entry.Fields[i].Value = s
+			masked++
+		}
+	}
+	return masked
+}
+
+// maskString replaces the matches of patterns in s.
+// It reports whether s contains any match.
+func maskString(patterns []piiPattern, s string) (string, bool) {
+	masked := false
+	for _, p := range patterns {
+		if !p.re.MatchString(s) {
+			continue
+		}
+		s = p.re.ReplaceAllLiteralString(s, p.mask)
+		masked = true
+	}
+	return s, masked
+}
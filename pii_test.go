@@ -0,0 +1,40 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestAddPIIPattern(t *testing.T) {
+	AddPIIPattern("email", PIIEmail)
+	AddPIIPattern("ssn", PIISSN)
+	AddPIIPattern("credit_card", PIICreditCard)
+	defer piiPatterns.Store(nil)
+
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.Info(context.Background(), "sent to alice@example.com", Fields{
+		"ssn":  "123-45-6789",
+		"card": "4111 1111 1111 1111",
+		"age":  20,
+		"note": "nothing to hide",
+	})
+
+	want := `{"level":"info","message":"sent to [REDACTED:email]","age":20,"card":"[REDACTED:credit_card]","note":"nothing to hide","ssn":"[REDACTED:ssn]"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+	if got := l.Stats().Masked; got != 3 {
+		t.Errorf("unexpected masked count: got %d, want 3", got)
+	}
+
+	// nil unregisters the pattern.
+	buf.Reset()
+	AddPIIPattern("email", nil)
+	l.Info(context.Background(), "sent to alice@example.com", nil)
+	want = `{"level":"info","message":"sent to alice@example.com"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
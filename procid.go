@@ -0,0 +1,28 @@
+//go:build ctxlog_procid
+
+package ctxlog
+
+import (
+	_ "unsafe" // for go:linkname
+)
+
+// The processor id relies on the internals of the runtime,
+// and it may break with any release of Go.
+// It is enabled only with the ctxlog_procid build tag.
+
+//go:linkname runtime_procPin runtime.procPin
+func runtime_procPin() int
+
+//go:linkname runtime_procUnpin runtime.procUnpin
+func runtime_procUnpin()
+
+const procIDSupported = true
+
+// procID returns the id of the P that runs the current goroutine.
+// The goroutine may migrate to another P right after procID returns,
+// so the id is just a hint.
+func procID() int {
+	id := runtime_procPin()
+	runtime_procUnpin()
+	return id
+}
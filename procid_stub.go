@@ -0,0 +1,9 @@
+//go:build !ctxlog_procid
+
+package ctxlog
+
+const procIDSupported = false
+
+func procID() int {
+	return -1
+}
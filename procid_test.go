@@ -0,0 +1,31 @@
+//go:build ctxlog_procid
+
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"testing"
+)
+
+func TestProcID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lprocid)
+	l.Info(context.Background(), "hello", nil)
+
+	var got struct {
+		ProcID *int `json:"procid"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ProcID == nil {
+		t.Fatal("procid field is missing")
+	}
+	if *got.ProcID < 0 || *got.ProcID >= runtime.GOMAXPROCS(0) {
+		t.Errorf("unexpected procid: %d", *got.ProcID)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build race
+
+package ctxlog
+
+const raceEnabled = true
@@ -0,0 +1,132 @@
+package ctxlog
+
+import "strings"
+
+// redacted replaces the values of the fields set by SetRedactKeys.
+const redacted = "[REDACTED]"
+
+// RedactKeys returns the keys set by SetRedactKeys.
+func (l *Logger) RedactKeys() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.redactKeys
+}
+
+// SetRedactKeys sets the keys of the fields whose values are replaced with "[REDACTED]",
+// e.g. for keeping the passwords and the tokens out of the logs:
+//
+//	logger.SetRedactKeys("password", "authorization")
+//
+// The keys are matched case-insensitively against the keys written to the output,
+// including the ones prefixed with "field." by the conflicts with the reserved fields.
+// The fields from all the sources, such as the context, With and the per-call fields, are redacted,
+// and so are the keys of the nested Fields, map[string]any and map[string]string values.
+// The maps passed by the callers are not modified.
+// If no key is given, no field is redacted.
+func (l *Logger) SetRedactKeys(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(keys) == 0 {
+		l.redactKeys = nil
+		return
+	}
+	l.redactKeys = append([]string(nil), keys...)
+}
+
+// SetRedactKeys sets the keys of the fields whose values are redacted of the standard logger.
+func SetRedactKeys(keys ...string) {
+	std.SetRedactKeys(keys...)
+}
+
+// redactFields replaces the values of the fields that match keys.
+func redactFields(fields []KV, keys []string) {
+	for i, f := range fields {
+		if matchRedactKey(f.Key, keys) {
+			if args, ok := f.Value.([]any); ok && strings.EqualFold(strings.TrimPrefix(f.Key, "field."), sqlArgsKey) {
+				// keep the types of the arguments of SQLFields for debugging the queries.
+				fields[i].Value = sqlArgTypes(args)
+				continue
+			}
+			fields[i].Value = redacted
+			continue
+		}
+		if v, ok := redactValue(f.Value, keys, 0); ok {
+			fields[i].Value = v
+		}
+	}
+}
+
+// matchRedactKey reports whether key matches any of keys.
+func matchRedactKey(key string, keys []string) bool {
+	key = strings.TrimPrefix(key, "field.")
+	for _, k := range keys {
+		if strings.EqualFold(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue returns a copy of the nested map v with the matching keys redacted.
+// It reports whether v has any matching key.
+// The maps deeper than maxDepth are returned as is, which guards against self-referential maps.
+func redactValue(v any, keys []string, depth int) (any, bool) {
+	if depth >= maxDepth {
+		return v, false
+	}
+	switch v := v.(type) {
+	case Fields:
+		m, ok := redactMap(v, keys, depth)
+		return Fields(m), ok
+	case map[string]any:
+		return redactMap(v, keys, depth)
+	case map[string]string:
+		if !hasRedactKey(v, keys) {
+			return v, false
+		}
+		m := make(map[string]string, len(v))
+		for k, vv := range v {
+			if matchRedactKey(k, keys) {
+				vv = redacted
+			}
+			m[k] = vv
+		}
+		return m, true
+	}
+	return v, false
+}
+
+func redactMap(v map[string]any, keys []string, depth int) (map[string]any, bool) {
+	var m map[string]any
+	for k, vv := range v {
+		var changed bool
+		if matchRedactKey(k, keys) {
+			vv, changed = redacted, true
+		} else {
+			vv, changed = redactValue(vv, keys, depth+1)
+		}
+		if !changed {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]any, len(v))
+			for k, vv := range v {
+				m[k] = vv
+			}
+		}
+		m[k] = vv
+	}
+	if m == nil {
+		return v, false
+	}
+	return m, true
+}
+
+func hasRedactKey(v map[string]string, keys []string) bool {
+	for k := range v {
+		if matchRedactKey(k, keys) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSetRedactKeys(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetRedactKeys("Password", "token", "level")
+	child := l.With(Fields{"token": "bound-token"})
+
+	nested := map[string]any{"Token": "nested-token", "name": "alice"}
+	ctx := With(context.Background(), Fields{"password": "secret"})
+	child.Info(ctx, "login", Fields{
+		"passwordHint": "pet's name",
+		"level":        "admin",
+		"user":         nested,
+	})
+
+	want := `{"level":"info","message":"login","field.level":"[REDACTED]","password":"[REDACTED]","passwordHint":"pet's name","token":"[REDACTED]","user":{"Token":"[REDACTED]","name":"alice"}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+	if nested["Token"] != "nested-token" {
+		t.Errorf("the field of the caller is modified: %v", nested)
+	}
+}
+
+func TestSetRedactKeys_SelfReference(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetRedactKeys("token")
+
+	m := map[string]any{"token": "secret"}
+	m["self"] = m
+	l.Info(context.Background(), "hello", Fields{"m": m})
+
+	if buf.Len() == 0 {
+		t.Error("want the entry written")
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Errorf("the token is not redacted: %s", buf.String())
+	}
+	if m["token"] != "secret" {
+		t.Errorf("the field of the caller is modified: %v", m["token"])
+	}
+}
@@ -0,0 +1,235 @@
+package ctxlog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFileWriter is an io.WriteCloser that writes to a file,
+// and rotates it when it grows larger than MaxSize or older than MaxAge.
+// The rotated files are renamed with the time of the rotation,
+// e.g. "app.log" is renamed to "app-2001-02-03T04-05-06.123456789.log".
+//
+// Set it to a logger by SetOutput:
+//
+//	w := &ctxlog.RotatingFileWriter{Filename: "app.log", MaxSize: 100 << 20, MaxBackups: 5}
+//	defer w.Close()
+//	ctxlog.SetOutput(w)
+//
+// It is safe for concurrent use.
+type RotatingFileWriter struct {
+	// Filename is the name of the file to write.
+	Filename string
+
+	// MaxSize is the maximum size of the file in bytes.
+	// If it is zero, the file is not rotated by size.
+	MaxSize int64
+
+	// MaxAge is the maximum duration since the file is opened.
+	// If it is zero, the file is not rotated by age.
+	MaxAge time.Duration
+
+	// MaxBackups is the maximum number of the rotated files to keep.
+	// If it is zero, all rotated files are kept.
+	MaxBackups int
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+var _ io.WriteCloser = (*RotatingFileWriter)(nil)
+
+// Write implements io.Writer.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Sync commits the current contents of the file to stable storage.
+func (w *RotatingFileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close implements io.Closer.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotatingFileWriter) open() error {
+	f, err := os.OpenFile(w.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = fi.Size()
+	w.openedAt = time.Now()
+	return nil
+}
+
+func (w *RotatingFileWriter) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.MaxSize > 0 && w.size+n > w.MaxSize {
+		return true
+	}
+	if w.MaxAge > 0 && time.Since(w.openedAt) >= w.MaxAge {
+		return true
+	}
+	return false
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	if err := os.Rename(w.Filename, w.backupName(time.Now())); err != nil {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	return w.removeOldBackups()
+}
+
+func (w *RotatingFileWriter) splitFilename() (prefix, ext string) {
+	ext = filepath.Ext(w.Filename)
+	prefix = strings.TrimSuffix(w.Filename, ext) + "-"
+	return
+}
+
+// backupTimeLayout is the layout of the time in the names of the backups.
+const backupTimeLayout = "2006-01-02T15-04-05.000000000"
+
+func (w *RotatingFileWriter) backupName(t time.Time) string {
+	prefix, ext := w.splitFilename()
+	name := prefix + t.Format(backupTimeLayout)
+	backup := name + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			return backup
+		}
+		backup = name + "." + strconv.Itoa(i) + ext
+	}
+}
+
+// backup is a backup file made by rotate.
+type backup struct {
+	name string
+	time time.Time
+	seq  int // the suffix added by backupName for the same time
+}
+
+// parseBackupName parses the name made by backupName.
+// It reports false for the other files that share prefix and ext, such as app-access.log of app.log.
+func parseBackupName(name, prefix, ext string) (backup, bool) {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) || len(name) < len(prefix)+len(ext) {
+		return backup{}, false
+	}
+	middle := name[len(prefix) : len(name)-len(ext)]
+	if len(middle) < len(backupTimeLayout) {
+		return backup{}, false
+	}
+	t, err := time.Parse(backupTimeLayout, middle[:len(backupTimeLayout)])
+	if err != nil {
+		return backup{}, false
+	}
+	var seq int
+	if rest := middle[len(backupTimeLayout):]; rest != "" {
+		if rest[0] != '.' {
+			return backup{}, false
+		}
+		seq, err = strconv.Atoi(rest[1:])
+		if err != nil || seq <= 0 || rest[1] == '+' {
+			return backup{}, false
+		}
+	}
+	return backup{name: name, time: t, seq: seq}, true
+}
+
+func (w *RotatingFileWriter) removeOldBackups() error {
+	if w.MaxBackups <= 0 {
+		return nil
+	}
+	prefix, ext := w.splitFilename()
+	matches, err := filepath.Glob(escapeGlob(prefix) + "*" + escapeGlob(ext))
+	if err != nil {
+		return err
+	}
+	var backups []backup
+	for _, name := range matches {
+		if b, ok := parseBackupName(name, prefix, ext); ok {
+			backups = append(backups, b)
+		}
+	}
+	if len(backups) <= w.MaxBackups {
+		return nil
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.Before(backups[j].time)
+		}
+		return backups[i].seq < backups[j].seq
+	})
+	for _, b := range backups[:len(backups)-w.MaxBackups] {
+		if err := os.Remove(b.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package ctxlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileWriter_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	w := &RotatingFileWriter{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    100,
+		MaxBackups: 2,
+	}
+	defer w.Close()
+
+	l := New(w, "", 0)
+	for i := 0; i < 10; i++ {
+		l.Info(context.Background(), "hello world", Fields{"i": i})
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Errorf("unexpected number of backups: got %d, want 2: %v", len(matches), matches)
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() > 100 {
+		t.Errorf("the file is too large: %d", fi.Size())
+	}
+}
+
+func TestRotatingFileWriter_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	w := &RotatingFileWriter{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxAge:   time.Hour,
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	w.openedAt = w.openedAt.Add(-2 * time.Hour)
+	if _, err := w.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second\n" {
+		t.Errorf("got %q, want %q", string(data), "second\n")
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("unexpected number of backups: got %d, want 1: %v", len(matches), matches)
+	}
+}
+
+func TestRotatingFileWriter_Siblings(t *testing.T) {
+	dir := t.TempDir()
+	siblings := []string{
+		"app-access.log",
+		"app-access-2001-02-03T04-05-06.000000000.log",
+		"app-0000.log",
+	}
+	for _, name := range siblings {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("keep\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := &RotatingFileWriter{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    100,
+		MaxBackups: 2,
+	}
+	defer w.Close()
+	l := New(w, "", 0)
+	for i := 0; i < 10; i++ {
+		l.Info(context.Background(), "hello world", Fields{"i": i})
+	}
+
+	for _, name := range siblings {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("the unrelated file is removed: %v", err)
+		}
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "app-[0-9][0-9][0-9][0-9]-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Errorf("unexpected number of backups: got %d, want 2: %v", len(matches), matches)
+	}
+}
+
+func TestParseBackupName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+		seq  int
+	}{
+		{"app-2001-02-03T04-05-06.000000000.log", true, 0},
+		{"app-2001-02-03T04-05-06.000000000.2.log", true, 2},
+		{"app-access.log", false, 0},
+		{"app-2001-02-03T04-05-06.000000000.x.log", false, 0},
+		{"app-2001-02-03T04-05-06.000000000.+1.log", false, 0},
+		{"app-2001-02-03T04-05-06.000000000..log", false, 0},
+		{"app-2001-02-03T04-05-06.log", false, 0},
+	}
+	for _, tt := range tests {
+		b, ok := parseBackupName(tt.name, "app-", ".log")
+		if ok != tt.ok || b.seq != tt.seq {
+			t.Errorf("%s: got (%d, %t), want (%d, %t)", tt.name, b.seq, ok, tt.seq, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package ctxlog
+
+import (
+	"sync"
+	"time"
+)
+
+// Sampler decides whether an entry is logged.
+// It is consulted after the level check, so it sees only the entries that are enabled.
+type Sampler interface {
+	// Sample reports whether the entry with the level and the message should be logged.
+	Sample(level Level, msg string) bool
+}
+
+// CountingSampler is a Sampler that counts the entries dropped.
+// The entry logged after some entries with the same level and message are dropped
+// carries the number of them as the "sampled" field,
+// so that the aggregators can estimate the actual number of the entries.
+type CountingSampler interface {
+	Sampler
+
+	// SampleCount is like Sample, and also returns the number of the entries
+	// with the same level and message dropped since the last logged one.
+	SampleCount(level Level, msg string) (ok bool, dropped int)
+}
+
+// maxSampleKeys is the maximum number of the levels and the messages counted by CountSampler.
+const maxSampleKeys = 4096
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+type sampleCount struct {
+	n       int // the number of the entries in the interval
+	dropped int // the number of the entries dropped since the last logged one
+}
+
+// CountSampler is a Sampler that logs the first First entries
+// with the same level and message in each Interval, and then every Thereafter-th entry.
+// If Interval is zero, the counts are never reset,
+// except that they are reset when the entries with more than 4096 distinct levels and messages are counted,
+// which bounds the memory for the messages of high cardinality.
+// It is a CountingSampler, so the logged entries carry the number of the dropped ones as the "sampled" field.
+// It is safe for concurrent use.
+//
+//	logger.SetSampler(&ctxlog.CountSampler{
+//		Interval:     time.Second,
+//		First:        100,
+//		Thereafter:   100,
+//		ExemptErrors: true,
+//	})
+type CountSampler struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+
+	// ExemptErrors exempts the entries at error level or above from sampling,
+	// so that they are always logged and don't count.
+	ExemptErrors bool
+
+	mu     sync.Mutex
+	reset  time.Time
+	counts map[sampleKey]*sampleCount
+}
+
+var _ CountingSampler = (*CountSampler)(nil)
+
+// Sample implements Sampler.
+func (s *CountSampler) Sample(level Level, msg string) bool {
+	ok, _ := s.SampleCount(level, msg)
+	return ok
+}
+
+// SampleCount implements CountingSampler.
+func (s *CountSampler) SampleCount(level Level, msg string) (bool, int) {
+	if s.ExemptErrors && level >= LevelError {
+		return true, 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Interval > 0 {
+		now := time.Now()
+		if !now.Before(s.reset) {
+			s.reset = now.Add(s.Interval)
+			s.resetCounts()
+		}
+	}
+	if s.counts == nil {
+		s.counts = make(map[sampleKey]*sampleCount)
+	}
+
+	key := sampleKey{level: level, msg: msg}
+	c, ok := s.counts[key]
+	if !ok {
+		if len(s.counts) >= maxSampleKeys {
+			s.resetCounts()
+			if len(s.counts) >= maxSampleKeys {
+				clear(s.counts)
+			}
+		}
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.n++
+	if c.n <= s.First || (s.Thereafter > 0 && (c.n-s.First)%s.Thereafter == 0) {
+		dropped := c.dropped
+		c.dropped = 0
+		return true, dropped
+	}
+	c.dropped++
+	return false, 0
+}
+
+// resetCounts starts counting the entries again.
+// It keeps the dropped counts until the next entries are logged.
+func (s *CountSampler) resetCounts() {
+	for key, c := range s.counts {
+		if c.dropped == 0 {
+			delete(s.counts, key)
+		} else {
+			c.n = 0
+		}
+	}
+}
+
+// sample reports whether the entry with the level and the message should be logged by s,
+// and the number of the entries dropped before it if s is a CountingSampler.
+func sample(s Sampler, level Level, msg string) (bool, int) {
+	if cs, ok := s.(CountingSampler); ok {
+		return cs.SampleCount(level, msg)
+	}
+	return s.Sample(level, msg), 0
+}
+
+// levelSampler samples only the entries at max level or below.
+type levelSampler struct {
+	max     Level
+	sampler Sampler
+}
+
+func (s *levelSampler) Sample(level Level, msg string) bool {
+	ok, _ := s.SampleCount(level, msg)
+	return ok
+}
+
+func (s *levelSampler) SampleCount(level Level, msg string) (bool, int) {
+	if level > s.max {
+		return true, 0
+	}
+	return sample(s.sampler, level, msg)
+}
+
+// Sampler returns the sampler of the logger.
+func (l *Logger) Sampler() Sampler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.sampler
+}
+
+// SetSampler sets the sampler of the logger.
+// If it is nil, which is the default, every entry is logged.
+// If it is a CountingSampler, the entries logged after the dropped ones carry the "sampled" field.
+func (l *Logger) SetSampler(s Sampler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sampler = s
+}
@@ -0,0 +1,140 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCountSampler(t *testing.T) {
+	s := &CountSampler{
+		First:      2,
+		Thereafter: 3,
+	}
+
+	var got []bool
+	for i := 0; i < 8; i++ {
+		got = append(got, s.Sample(LevelInfo, "hello"))
+	}
+	want := []bool{true, true, false, false, true, false, false, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d: got %t, want %t", i, got[i], want[i])
+		}
+	}
+
+	// another message is counted separately.
+	if !s.Sample(LevelInfo, "world") {
+		t.Error("want true, but got false")
+	}
+}
+
+func TestCountSampler_ExemptErrors(t *testing.T) {
+	s := &CountSampler{
+		First:        1,
+		ExemptErrors: true,
+	}
+
+	for i := 0; i < 3; i++ {
+		if !s.Sample(LevelError, "failed") {
+			t.Errorf("%d: want error level exempted, got dropped", i)
+		}
+	}
+	if !s.Sample(LevelWarn, "retry") {
+		t.Error("want the first warn logged, got dropped")
+	}
+	if s.Sample(LevelWarn, "retry") {
+		t.Error("want the second warn dropped, got logged")
+	}
+}
+
+func TestCountSampler_SampleCount(t *testing.T) {
+	s := &CountSampler{
+		First:      1,
+		Thereafter: 3,
+	}
+
+	type result struct {
+		ok      bool
+		dropped int
+	}
+	var got []result
+	for i := 0; i < 7; i++ {
+		ok, dropped := s.SampleCount(LevelDebug, "hello")
+		got = append(got, result{ok, dropped})
+	}
+	want := []result{{true, 0}, {false, 0}, {false, 0}, {true, 2}, {false, 0}, {false, 0}, {true, 2}}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCountSampler_IntervalKeepsDropped(t *testing.T) {
+	s := &CountSampler{
+		Interval: time.Hour,
+		First:    1,
+	}
+	s.SampleCount(LevelInfo, "hello")
+	s.SampleCount(LevelInfo, "hello")
+	s.SampleCount(LevelInfo, "world")
+
+	// start the next interval.
+	s.mu.Lock()
+	s.reset = time.Now()
+	s.mu.Unlock()
+
+	if ok, dropped := s.SampleCount(LevelInfo, "hello"); !ok || dropped != 1 {
+		t.Errorf("got (%t, %d), want (true, 1)", ok, dropped)
+	}
+	if _, ok := s.counts[sampleKey{level: LevelInfo, msg: "world"}]; ok {
+		t.Error("want the count without drops removed")
+	}
+}
+
+func TestSetSampler_Sampled(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetSampler(&levelSampler{
+		max:     LevelInfo,
+		sampler: &CountSampler{First: 1, Thereafter: 2},
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		l.Info(ctx, "hello", Fields{"n": i})
+		l.Warn(ctx, "retry", nil)
+	}
+
+	want := `{"level":"info","message":"hello","n":0}` + "\n" +
+		`{"level":"warn","message":"retry"}` + "\n" +
+		`{"level":"warn","message":"retry"}` + "\n" +
+		`{"level":"info","message":"hello","n":2,"sampled":1}` + "\n" +
+		`{"level":"warn","message":"retry"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestCountSampler_MaxKeys(t *testing.T) {
+	s := &CountSampler{First: 1}
+	for i := 0; i < maxSampleKeys*2; i++ {
+		s.Sample(LevelInfo, strconv.Itoa(i))
+	}
+	if got := len(s.counts); got > maxSampleKeys {
+		t.Errorf("want at most %d counts, got %d", maxSampleKeys, got)
+	}
+
+	// the dropped counts survive the reset.
+	s.Sample(LevelInfo, "hello")
+	s.Sample(LevelInfo, "hello")
+	for i := 0; i < maxSampleKeys; i++ {
+		s.Sample(LevelDebug, strconv.Itoa(i))
+	}
+	if ok, dropped := s.SampleCount(LevelInfo, "hello"); !ok || dropped != 1 {
+		t.Errorf("got (%t, %d), want (true, 1)", ok, dropped)
+	}
+}
@@ -0,0 +1,192 @@
+package ctxlog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// slogHandler is a slog.Handler backed by a Logger.
+type slogHandler struct {
+	l      *Logger
+	fields Fields // the attributes bound by WithAttrs
+	prefix string // the prefix of the keys added by WithGroup, such as "group."
+}
+
+var _ slog.Handler = (*slogHandler)(nil)
+
+// NewSlogHandler returns a slog.Handler that writes the records through l,
+// so that log/slog gets the context fields and the JSON encoder of ctxlog:
+//
+//	logger := slog.New(ctxlog.NewSlogHandler(ctxlog.Default()))
+//	logger.InfoContext(ctx, "hello", "user", "alice")
+//
+// The time, the level and the message of the records are written as the reserved fields
+// "time", "level" and "message". The groups become the dotted prefixes of the keys.
+// The levels of slog are mapped to the nearest lower levels of ctxlog.
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// slogLevel converts the level of slog to the level of ctxlog.
+func slogLevel(level slog.Level) Level {
+	switch {
+	case level >= slog.LevelError:
+		return LevelError
+	case level >= slog.LevelWarn:
+		return LevelWarn
+	case level >= slog.LevelInfo:
+		return LevelInfo
+	case level >= slog.LevelDebug:
+		return LevelDebug
+	}
+	return LevelTrace
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.l.EnabledContext(ctx, slogLevel(level))
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(fields, h.prefix, a)
+		return true
+	})
+	if r.PC != 0 {
+		ctx = withCallerPC(ctx, r.PC)
+	}
+	if !r.Time.IsZero() {
+		ctx = withRecordTime(ctx, r.Time)
+	}
+	return h.l.OutputContext(ctx, 1, slogLevel(r.Level), r.Message, fields)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make(Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addSlogAttr(fields, h.prefix, a)
+	}
+	return &slogHandler{
+		l:      h.l,
+		fields: fields,
+		prefix: h.prefix,
+	}
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{
+		l:      h.l,
+		fields: h.fields,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+// addSlogAttr adds a to fields.
+// The values of slog.LogValuer are resolved, and the groups are flattened into dotted keys.
+func addSlogAttr(fields Fields, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, aa := range attrs {
+			addSlogAttr(fields, prefix, aa)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
+
+// NewFromSlogHandler returns a new Logger that writes the entries through h,
+// so that the call sites of ctxlog can emit through an existing slog pipeline:
+//
+//	logger := ctxlog.NewFromSlogHandler(slog.NewJSONHandler(os.Stderr, nil))
+//	logger.Info(ctx, "hello", ctxlog.Fields{"user": "alice"})
+//
+// The fields of the entries, including the context fields and the fields bound by With,
+// become the attributes of the records.
+// The level of the logger is LevelTrace, so that h decides which levels are enabled.
+// The output and the formatter of the logger are not used.
+func NewFromSlogHandler(h slog.Handler) *Logger {
+	l := New(nil, "", 0)
+	l.level = LevelTrace
+	l.slogHandler = h
+	return l
+}
+
+// SlogHandler returns the handler set by NewFromSlogHandler.
+func (l *Logger) SlogHandler() slog.Handler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.slogHandler
+}
+
+// levelToSlog converts the level of ctxlog to the level of slog.
+// The levels without the counterparts are placed at the distance of 4, like the levels of slog.
+func levelToSlog(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo, LevelNo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	case LevelFatal:
+		return slog.LevelError + 4
+	case LevelPanic:
+		return slog.LevelError + 8
+	}
+	return slog.LevelDebug - 4 + slog.Level(level-LevelTrace)
+}
+
+// handleSlog writes entry through h.
+// calldepth is the depth of the caller from the caller of handleSlog.
+func handleSlog(ctx context.Context, h slog.Handler, entry *Entry, calldepth int) error {
+	level := levelToSlog(entry.Level)
+	if !h.Enabled(ctx, level) {
+		return nil
+	}
+
+	pc, _ := ctx.Value(keyCallerPC).(uintptr)
+	if pc == 0 {
+		var pcs [1]uintptr
+		runtime.Callers(calldepth+2, pcs[:])
+		pc = pcs[0]
+	}
+	t := entry.Time
+	if entry.Flags&LUTC != 0 {
+		t = t.In(time.UTC)
+	}
+	r := slog.NewRecord(t, level, entry.Message, pc)
+	for _, f := range entry.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	return h.Handle(ctx, r)
+}
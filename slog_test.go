@@ -0,0 +1,162 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type testLogValuer struct {
+	name string
+}
+
+func (v testLogValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", v.name))
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lshortfile)
+	l.SetLevel(LevelInfo)
+	logger := slog.New(NewSlogHandler(l))
+
+	ctx := With(context.Background(), Fields{"request_id": "req-1"})
+	logger.DebugContext(ctx, "filtered")
+	logger.With("component", "billing").WithGroup("req").InfoContext(ctx, "hello", "user", testLogValuer{name: "alice"}, slog.Int("status", 200))
+
+	want := `{"level":"info","message":"hello","file":"slog_test.go","line":30,"component":"billing","req.status":200,"req.user.name":"alice","request_id":"req-1"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSlogHandler_Enabled(t *testing.T) {
+	l := New(new(bytes.Buffer), "", 0)
+	l.SetLevel(LevelWarn)
+	h := NewSlogHandler(l)
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("want info disabled, got enabled")
+	}
+	if !h.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("want warn enabled, got disabled")
+	}
+
+	// slog skips building the records for the discarding logger.
+	h = NewSlogHandler(New(io.Discard, "", 0))
+	if h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("want disabled for io.Discard, got enabled")
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	tests := []struct {
+		in   slog.Level
+		want Level
+	}{
+		{slog.LevelDebug - 1, LevelTrace},
+		{slog.LevelDebug, LevelDebug},
+		{slog.LevelInfo, LevelInfo},
+		{slog.LevelInfo + 1, LevelInfo},
+		{slog.LevelWarn, LevelWarn},
+		{slog.LevelError, LevelError},
+		{slog.LevelError + 4, LevelError},
+	}
+	for _, tt := range tests {
+		if got := slogLevel(tt.in); got != tt.want {
+			t.Errorf("slogLevel(%v): got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewFromSlogHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h := slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Attr{}
+			case slog.SourceKey:
+				src := a.Value.Any().(*slog.Source)
+				return slog.String("source", filepath.Base(src.File)+":"+strconv.Itoa(src.Line))
+			}
+			return a
+		},
+	})
+	l := NewFromSlogHandler(h).With(Fields{"component": "billing"})
+
+	ctx := With(context.Background(), Fields{"request_id": "req-1"})
+	l.Debug(ctx, "filtered", nil)
+	l.Info(ctx, "hello", Fields{"user": "alice"})
+	l.Error(ctx, "failed", nil)
+
+	want := `{"level":"INFO","source":"slog_test.go:96","msg":"hello","component":"billing","request_id":"req-1","user":"alice"}` + "\n" +
+		`{"level":"ERROR","source":"slog_test.go:97","msg":"failed","component":"billing","request_id":"req-1"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestEnabledContext(t *testing.T) {
+	ctx := context.Background()
+	l := New(new(bytes.Buffer), "", 0)
+	l.SetLevel(LevelInfo)
+	if l.EnabledContext(ctx, LevelDebug) {
+		t.Error("want debug disabled, got enabled")
+	}
+	if !l.EnabledContext(ctx, LevelInfo) {
+		t.Error("want info enabled, got disabled")
+	}
+
+	// the slog handler decides the levels.
+	h := slog.NewJSONHandler(new(bytes.Buffer), &slog.HandlerOptions{Level: slog.LevelWarn})
+	l = NewFromSlogHandler(h)
+	if l.EnabledContext(ctx, LevelInfo) {
+		t.Error("want info disabled by the handler, got enabled")
+	}
+	if !l.EnabledContext(ctx, LevelError) {
+		t.Error("want error enabled, got disabled")
+	}
+}
+
+func TestLevelToSlog(t *testing.T) {
+	tests := []struct {
+		in   Level
+		want slog.Level
+	}{
+		{LevelTrace - 1, slog.LevelDebug - 5},
+		{LevelTrace, slog.LevelDebug - 4},
+		{LevelDebug, slog.LevelDebug},
+		{LevelInfo, slog.LevelInfo},
+		{LevelWarn, slog.LevelWarn},
+		{LevelError, slog.LevelError},
+		{LevelFatal, slog.LevelError + 4},
+		{LevelPanic, slog.LevelError + 8},
+	}
+	for _, tt := range tests {
+		if got := levelToSlog(tt.in); got != tt.want {
+			t.Errorf("levelToSlog(%v): got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSlogHandler_RecordTime(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Ldate|Ltime|Lmicroseconds|LUTC)
+	h := NewSlogHandler(l)
+
+	r := slog.NewRecord(time.Date(2001, 2, 3, 4, 5, 6, 123456000, time.UTC), slog.LevelInfo, "hello", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2001-02-03T04:05:06.123456Z","level":"info","message":"hello"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
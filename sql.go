@@ -0,0 +1,38 @@
+package ctxlog
+
+import "fmt"
+
+// sqlArgsKey is the key of the bound arguments emitted by SQLFields.
+const sqlArgsKey = "sql_args"
+
+// SQLFields returns the fields for logging the SQL query and its bound arguments.
+// The query is emitted as the "sql" field, and the arguments are emitted as the "sql_args" field.
+// If "sql_args" is one of the keys set by SetRedactKeys, only the types of the arguments are emitted,
+// as RedactedSQLFields does.
+func SQLFields(query string, args ...any) Fields {
+	if args == nil {
+		args = []any{}
+	}
+	return Fields{
+		"sql":      query,
+		sqlArgsKey: args,
+	}
+}
+
+// RedactedSQLFields is like SQLFields, but it emits only the types of the arguments
+// so that the bound arguments don't leak personally identifiable information.
+func RedactedSQLFields(query string, args ...any) Fields {
+	return Fields{
+		"sql":      query,
+		sqlArgsKey: sqlArgTypes(args),
+	}
+}
+
+// sqlArgTypes returns the types of args.
+func sqlArgTypes(args []any) []string {
+	types := make([]string, 0, len(args))
+	for _, arg := range args {
+		types = append(types, fmt.Sprintf("%T", arg))
+	}
+	return types
+}
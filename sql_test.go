@@ -0,0 +1,62 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSQLFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.Info(context.Background(), "query", SQLFields("SELECT * FROM users WHERE id = ?", 42))
+
+	var got struct {
+		SQL     string `json:"sql"`
+		SQLArgs []any  `json:"sql_args"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SQL != "SELECT * FROM users WHERE id = ?" {
+		t.Errorf("unexpected sql: got %q", got.SQL)
+	}
+	if want := []any{float64(42)}; !reflect.DeepEqual(got.SQLArgs, want) {
+		t.Errorf("unexpected sql_args: got %v, want %v", got.SQLArgs, want)
+	}
+}
+
+func TestRedactedSQLFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.Info(context.Background(), "query", RedactedSQLFields("SELECT * FROM users WHERE email = ? AND age > ?", "alice@example.com", 20))
+
+	var got struct {
+		SQLArgs []string `json:"sql_args"`
+	}
+	t.Log(buf.String())
+	if bytes.Contains(buf.Bytes(), []byte("alice@example.com")) {
+		t.Error("the argument is not redacted")
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"string", "int"}; !reflect.DeepEqual(got.SQLArgs, want) {
+		t.Errorf("unexpected sql_args: got %v, want %v", got.SQLArgs, want)
+	}
+}
+
+func TestSQLFields_RedactKeys(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetRedactKeys("SQL_ARGS")
+	l.Info(context.Background(), "query", SQLFields("SELECT * FROM users WHERE email = ? AND age > ?", "alice@example.com", 20))
+
+	want := `{"level":"info","message":"query","sql":"SELECT * FROM users WHERE email = ? AND age \u003e ?","sql_args":["string","int"]}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
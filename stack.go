@@ -0,0 +1,195 @@
+package ctxlog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StackTracePolicy controls when the entries at the stack trace level or above carry the "stack" field.
+// The stack trace level is error level by default, see SetStackTraceLevel.
+type StackTracePolicy int
+
+const (
+	// StackTraceOff never emits stack traces. It is the default.
+	StackTraceOff StackTracePolicy = iota
+
+	// StackTraceOnError emits a stack trace with every entry at the stack trace level or above.
+	// If an error in the fields already carries a stack trace, it is emitted.
+	// Otherwise the stack trace of the caller is captured.
+	StackTraceOnError
+
+	// StackTraceConditional emits a stack trace only with the entries at the stack trace level or above
+	// that have an error in the fields, which carries a stack trace
+	// or matches a type registered by RegisterStackTraceType.
+	// The carried stack traces are emitted instead of re-capturing.
+	StackTraceConditional
+)
+
+// StackTracePolicy returns the policy of stack traces.
+func (l *Logger) StackTracePolicy() StackTracePolicy {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.stackPolicy
+}
+
+// SetStackTracePolicy sets the policy of stack traces, such as StackTraceConditional.
+//
+// An error carries a stack trace if it has a StackTrace method without arguments,
+// such as the errors of github.com/pkg/errors.
+// The result of StackTrace is formatted by the %+v verb of the fmt package.
+func (l *Logger) SetStackTracePolicy(policy StackTracePolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stackPolicy = policy
+}
+
+// StackTraceLevel returns the minimum level of the entries with stack traces.
+func (l *Logger) StackTraceLevel() Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.stackLevel
+}
+
+// SetStackTraceLevel sets the minimum level of the entries with stack traces.
+// The default is LevelError.
+// It has no effect with StackTraceOff, so it is usually used together with SetStackTracePolicy:
+//
+//	logger.SetStackTracePolicy(ctxlog.StackTraceOnError)
+//	logger.SetStackTraceLevel(ctxlog.LevelWarn)
+func (l *Logger) SetStackTraceLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stackLevel = level
+}
+
+var (
+	stackTraceTypesMu sync.RWMutex
+	stackTraceTypes   []reflect.Type
+)
+
+// RegisterStackTraceType registers the type of err.
+// With StackTraceConditional, the stack trace is captured for the errors of the type,
+// or the errors that wrap them.
+func RegisterStackTraceType(err error) {
+	stackTraceTypesMu.Lock()
+	defer stackTraceTypesMu.Unlock()
+	stackTraceTypes = append(stackTraceTypes, reflect.TypeOf(err))
+}
+
+func isStackTraceType(err error) bool {
+	stackTraceTypesMu.RLock()
+	defer stackTraceTypesMu.RUnlock()
+	for ; err != nil; err = errors.Unwrap(err) {
+		typ := reflect.TypeOf(err)
+		for _, t := range stackTraceTypes {
+			if typ == t {
+				return true
+			}
+		}
+		if isNilPointer(err) {
+			// the methods of the nil pointer may panic.
+			break
+		}
+	}
+	return false
+}
+
+// carriedStackTrace returns the stack trace that err or the errors wrapped by err carry.
+func carriedStackTrace(err error) (string, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if isNilPointer(err) {
+			// the methods of the nil pointer may panic.
+			break
+		}
+		m := reflect.ValueOf(err).MethodByName("StackTrace")
+		if !m.IsValid() {
+			continue
+		}
+		typ := m.Type()
+		if typ.NumIn() != 0 || typ.NumOut() != 1 {
+			continue
+		}
+		st := fmt.Sprintf("%+v", m.Call(nil)[0].Interface())
+		return strings.TrimPrefix(st, "\n"), true
+	}
+	return "", false
+}
+
+// stackTrace returns the stack trace for the entry with fields by policy.
+// skip is the number of the stack frames to skip, with 0 identifying the caller of stackTrace.
+func stackTrace(policy StackTracePolicy, fields []KV, skip int) (string, bool) {
+	var capture bool
+	switch policy {
+	case StackTraceOnError:
+		capture = true
+	case StackTraceConditional:
+	default:
+		return "", false
+	}
+
+	for _, f := range fields {
+		err, ok := f.Value.(error)
+		if !ok {
+			continue
+		}
+		if st, ok := carriedStackTrace(err); ok {
+			return st, true
+		}
+		if !capture && isStackTraceType(err) {
+			capture = true
+		}
+	}
+	if !capture {
+		return "", false
+	}
+	return captureStackTrace(skip + 1), true
+}
+
+// insertErrorStacks inserts the "<key>.stack" fields for the errors in fields
+// that carry stack traces, see the Lerrorstack flag.
+func (e *encodeState) insertErrorStacks(fields []KV) []KV {
+	var stacks []KV
+	for _, f := range fields {
+		err, ok := f.Value.(error)
+		if !ok {
+			continue
+		}
+		if st, ok := carriedStackTrace(err); ok {
+			stacks = append(stacks, KV{Key: f.Key + ".stack", Value: st})
+		}
+	}
+	for _, s := range stacks {
+		fields = e.insertField(fields, s.Key, s.Value)
+	}
+	return fields
+}
+
+// captureStackTrace returns the stack trace of the current goroutine.
+// skip is the number of the stack frames to skip, with 0 identifying the caller of captureStackTrace.
+func captureStackTrace(skip int) string {
+	var pcs [32]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testStackError struct{}
+
+func (testStackError) Error() string { return "stack error" }
+
+func (testStackError) StackTrace() []string {
+	return []string{"main.main", "runtime.main"}
+}
+
+type testRegisteredError struct{}
+
+func (testRegisteredError) Error() string { return "registered error" }
+
+func TestSetStackTracePolicy(t *testing.T) {
+	RegisterStackTraceType(testRegisteredError{})
+
+	tests := []struct {
+		name   string
+		policy StackTracePolicy
+		level  Level
+		err    error
+		want   string // prefix of the stack, or empty if no stack is emitted
+	}{
+		{"off", StackTraceOff, LevelError, errors.New("error"), ""},
+		{"on error", StackTraceOnError, LevelError, errors.New("error"), "github.com/shogo82148/ctxlog.TestSetStackTracePolicy"},
+		{"on error with info", StackTraceOnError, LevelInfo, errors.New("error"), ""},
+		{"conditional", StackTraceConditional, LevelError, errors.New("error"), ""},
+		{"conditional with registered type", StackTraceConditional, LevelError, fmt.Errorf("wrapped: %w", testRegisteredError{}), "github.com/shogo82148/ctxlog.TestSetStackTracePolicy"},
+		{"conditional with carried stack", StackTraceConditional, LevelError, fmt.Errorf("wrapped: %w", testStackError{}), "[main.main runtime.main]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			l := New(buf, "", 0)
+			l.SetStackTracePolicy(tt.policy)
+			l.OutputContext(context.Background(), 1, tt.level, "hello", Fields{"error": tt.err})
+
+			var got struct {
+				Stack *string
+			}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if got.Stack != nil {
+					t.Errorf("want no stack, got %q", *got.Stack)
+				}
+				return
+			}
+			if got.Stack == nil {
+				t.Fatal("stack is missing")
+			}
+			if !strings.HasPrefix(*got.Stack, tt.want) {
+				t.Errorf("want prefix %q, got %q", tt.want, *got.Stack)
+			}
+		})
+	}
+}
+
+func TestSetStackTraceLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetStackTracePolicy(StackTraceOnError)
+	l.SetStackTraceLevel(LevelWarn)
+	child := l.With(Fields{"component": "test"})
+	child.Info(context.Background(), "info", nil)
+	child.Warn(context.Background(), "warn", nil)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %d", len(lines))
+	}
+	var got struct {
+		Stack *string
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Stack != nil {
+		t.Errorf("want no stack at info level, got %q", *got.Stack)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Stack == nil {
+		t.Fatal("stack is missing at warn level")
+	}
+	if want := "github.com/shogo82148/ctxlog.TestSetStackTraceLevel"; !strings.HasPrefix(*got.Stack, want) {
+		t.Errorf("want prefix %q, got %q", want, *got.Stack)
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", Lerrorstack)
+	l.Info(context.Background(), "failed", Fields{
+		"err":   fmt.Errorf("wrapped: %w", testStackError{}),
+		"cause": errors.New("no stack"),
+	})
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["err"] != "wrapped: stack error" {
+		t.Errorf("unexpected err: got %v, want %q", got["err"], "wrapped: stack error")
+	}
+	if got["err.stack"] != "[main.main runtime.main]" {
+		t.Errorf("unexpected err.stack: got %v, want %q", got["err.stack"], "[main.main runtime.main]")
+	}
+	if _, ok := got["cause.stack"]; ok {
+		t.Errorf("unexpected cause.stack: %v", got["cause.stack"])
+	}
+}
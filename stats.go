@@ -0,0 +1,212 @@
+package ctxlog
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// LevelStats is the statistics of the entries at a log level.
+type LevelStats struct {
+	// Lines is the number of the entries written.
+	Lines uint64
+
+	// Bytes is the number of the bytes written.
+	Bytes uint64
+}
+
+// Stats is the statistics of a logger.
+type Stats struct {
+	// Levels is the statistics per log level.
+	// The levels less than LevelTrace are counted as LevelTrace.
+	Levels map[Level]LevelStats
+
+	// Masked is the number of the values masked by the patterns of AddPIIPattern.
+	Masked uint64
+}
+
+type levelCounter struct {
+	lines atomic.Uint64
+	bytes atomic.Uint64
+}
+
+type statsCounter struct {
+	levels [LevelDisabled - LevelTrace + 1]levelCounter
+	masked atomic.Uint64
+}
+
+func (s *statsCounter) counter(level Level) *levelCounter {
+	if level < LevelTrace {
+		level = LevelTrace
+	}
+	if level > LevelDisabled {
+		level = LevelDisabled
+	}
+	return &s.levels[level-LevelTrace]
+}
+
+func (s *statsCounter) add(level Level, n int64) {
+	c := s.counter(level)
+	c.lines.Add(1)
+	c.bytes.Add(uint64(n))
+}
+
+// Stats returns the cumulative statistics of the entries written by the logger.
+func (l *Logger) Stats() Stats {
+	levels := make(map[Level]LevelStats)
+	for level := LevelTrace; level <= LevelDisabled; level++ {
+		c := l.stats.counter(level)
+		lines := c.lines.Load()
+		if lines == 0 {
+			continue
+		}
+		levels[level] = LevelStats{
+			Lines: lines,
+			Bytes: c.bytes.Load(),
+		}
+	}
+	return Stats{
+		Levels: levels,
+		Masked: l.stats.masked.Load(),
+	}
+}
+
+// FieldStat is the running statistics of the values of a numeric field.
+type FieldStat struct {
+	// Count is the number of the values.
+	Count uint64
+
+	// Sum is the sum of the values.
+	Sum float64
+
+	// Min and Max are the minimum and the maximum of the values.
+	Min float64
+	Max float64
+}
+
+type fieldCounter struct {
+	count atomic.Uint64
+	sum   atomic.Uint64 // bits of float64
+	min   atomic.Uint64 // bits of float64
+	max   atomic.Uint64 // bits of float64
+}
+
+func newFieldCounter() *fieldCounter {
+	c := &fieldCounter{}
+	c.min.Store(math.Float64bits(math.Inf(1)))
+	c.max.Store(math.Float64bits(math.Inf(-1)))
+	return c
+}
+
+func (c *fieldCounter) add(v float64) {
+	c.count.Add(1)
+	for {
+		old := c.sum.Load()
+		if c.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			break
+		}
+	}
+	for {
+		old := c.min.Load()
+		if v >= math.Float64frombits(old) || c.min.CompareAndSwap(old, math.Float64bits(v)) {
+			break
+		}
+	}
+	for {
+		old := c.max.Load()
+		if v <= math.Float64frombits(old) || c.max.CompareAndSwap(old, math.Float64bits(v)) {
+			break
+		}
+	}
+}
+
+// SetFieldStatsKeys sets the keys of the numeric fields whose statistics are tracked,
+// and resets the statistics.
+// It enables a metrics-from-logs approach, e.g. tracking the distribution of latency
+// without a separate metrics pipeline.
+// The statistics are updated with atomic operations, so they don't serialize the logging.
+func (l *Logger) SetFieldStatsKeys(keys ...string) {
+	if len(keys) == 0 {
+		l.fieldStats.Store(nil)
+		return
+	}
+	counters := make(map[string]*fieldCounter, len(keys))
+	for _, key := range keys {
+		counters[key] = newFieldCounter()
+	}
+	l.fieldStats.Store(&counters)
+}
+
+// FieldStats returns the running statistics of the fields set by SetFieldStatsKeys.
+// The fields that have no numeric values yet are omitted.
+func (l *Logger) FieldStats() map[string]FieldStat {
+	stats := make(map[string]FieldStat)
+	counters := l.fieldStats.Load()
+	if counters == nil {
+		return stats
+	}
+	for key, c := range *counters {
+		count := c.count.Load()
+		if count == 0 {
+			continue
+		}
+		stats[key] = FieldStat{
+			Count: count,
+			Sum:   math.Float64frombits(c.sum.Load()),
+			Min:   math.Float64frombits(c.min.Load()),
+			Max:   math.Float64frombits(c.max.Load()),
+		}
+	}
+	return stats
+}
+
+// addFieldStats updates the statistics of the fields.
+func (l *Logger) addFieldStats(fields []KV) {
+	counters := l.fieldStats.Load()
+	if counters == nil {
+		return
+	}
+	for _, f := range fields {
+		c, ok := (*counters)[f.Key]
+		if !ok {
+			continue
+		}
+		if v, ok := toFloat64(f.Value); ok {
+			c.add(v)
+		}
+	}
+}
+
+// toFloat64 converts a numeric value to float64.
+// time.Duration is converted in nanoseconds.
+func toFloat64(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case time.Duration:
+		return float64(v), true
+	}
+	return 0, false
+}
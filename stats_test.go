@@ -0,0 +1,51 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.SetLevel(LevelInfo)
+
+	l.Debug(context.Background(), "filtered", nil)
+	l.Info(context.Background(), "hello", nil)
+	l.Info(context.Background(), "world", nil)
+	n := buf.Len()
+	l.Error(context.Background(), "error", nil)
+
+	stats := l.Stats()
+	if got, want := stats.Levels[LevelInfo], (LevelStats{Lines: 2, Bytes: uint64(n)}); got != want {
+		t.Errorf("unexpected info stats: got %+v, want %+v", got, want)
+	}
+	if got, want := stats.Levels[LevelError], (LevelStats{Lines: 1, Bytes: uint64(buf.Len() - n)}); got != want {
+		t.Errorf("unexpected error stats: got %+v, want %+v", got, want)
+	}
+	if _, ok := stats.Levels[LevelDebug]; ok {
+		t.Errorf("filtered entries are counted: %+v", stats.Levels[LevelDebug])
+	}
+}
+
+func TestFieldStats(t *testing.T) {
+	l := New(discard, "", 0)
+	l.SetFieldStatsKeys("latency", "size")
+
+	l.Info(context.Background(), "request", Fields{"latency": 10, "size": "large"})
+	l.Info(context.Background(), "request", Fields{"latency": 2.5})
+	l.Info(context.Background(), "request", Fields{"latency": int64(30), "other": 100})
+
+	stats := l.FieldStats()
+	want := FieldStat{Count: 3, Sum: 42.5, Min: 2.5, Max: 30}
+	if got := stats["latency"]; got != want {
+		t.Errorf("unexpected latency stats: got %+v, want %+v", got, want)
+	}
+	if _, ok := stats["size"]; ok {
+		t.Errorf("non-numeric values are counted: %+v", stats["size"])
+	}
+	if _, ok := stats["other"]; ok {
+		t.Errorf("untracked fields are counted: %+v", stats["other"])
+	}
+}
@@ -0,0 +1,93 @@
+package ctxlog
+
+import "context"
+
+// The template variants of the output functions, such as Infot, support the backends
+// that prefer structured messages: the template with {placeholders} is emitted
+// as the "message_template" field without rendering, and args are emitted as the fields.
+//
+//	ctxlog.Infot(ctx, "user {user} logged in", ctxlog.Fields{"user": "alice"})
+//	// {"level":"info","message":"user {user} logged in","message_template":"user {user} logged in","user":"alice"}
+//
+// The message is the template as is, so that the entries with the same template
+// are grouped by the message as well.
+
+// Tracet writes the output for a trace level logging event with a message template.
+func (l *Logger) Tracet(ctx context.Context, template string, args Fields) {
+	if !l.Enabled(LevelTrace) {
+		return
+	}
+	l.output(ctx, 2, LevelTrace, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Debugt writes the output for a debug level logging event with a message template.
+func (l *Logger) Debugt(ctx context.Context, template string, args Fields) {
+	if !l.Enabled(LevelDebug) {
+		return
+	}
+	l.output(ctx, 2, LevelDebug, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Infot writes the output for an info level logging event with a message template.
+func (l *Logger) Infot(ctx context.Context, template string, args Fields) {
+	if !l.Enabled(LevelInfo) {
+		return
+	}
+	l.output(ctx, 2, LevelInfo, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Warnt writes the output for a warn level logging event with a message template.
+func (l *Logger) Warnt(ctx context.Context, template string, args Fields) {
+	if !l.Enabled(LevelWarn) {
+		return
+	}
+	l.output(ctx, 2, LevelWarn, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Errort writes the output for an error level logging event with a message template.
+func (l *Logger) Errort(ctx context.Context, template string, args Fields) {
+	if !l.Enabled(LevelError) {
+		return
+	}
+	l.output(ctx, 2, LevelError, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Tracet writes the output for a trace level logging event with a message template.
+func Tracet(ctx context.Context, template string, args Fields) {
+	if !std.Enabled(LevelTrace) {
+		return
+	}
+	std.output(ctx, 2, LevelTrace, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Debugt writes the output for a debug level logging event with a message template.
+func Debugt(ctx context.Context, template string, args Fields) {
+	if !std.Enabled(LevelDebug) {
+		return
+	}
+	std.output(ctx, 2, LevelDebug, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Infot writes the output for an info level logging event with a message template.
+func Infot(ctx context.Context, template string, args Fields) {
+	if !std.Enabled(LevelInfo) {
+		return
+	}
+	std.output(ctx, 2, LevelInfo, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Warnt writes the output for a warn level logging event with a message template.
+func Warnt(ctx context.Context, template string, args Fields) {
+	if !std.Enabled(LevelWarn) {
+		return
+	}
+	std.output(ctx, 2, LevelWarn, template, []KV{{Key: "message_template", Value: template}}, args)
+}
+
+// Errort writes the output for an error level logging event with a message template.
+func Errort(ctx context.Context, template string, args Fields) {
+	if !std.Enabled(LevelError) {
+		return
+	}
+	std.output(ctx, 2, LevelError, template, []KV{{Key: "message_template", Value: template}}, args)
+}
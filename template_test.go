@@ -0,0 +1,18 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestInfot(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+	l.Infot(context.Background(), "user {user} logged in", Fields{"user": "alice"})
+
+	want := `{"level":"info","message":"user {user} logged in","message_template":"user {user} logged in","user":"alice"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
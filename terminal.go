@@ -0,0 +1,20 @@
+package ctxlog
+
+import (
+	"io"
+	"os"
+)
+
+// isTerminal reports whether w is a terminal.
+// It treats every character device as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,35 @@
+package ctxlog
+
+import "time"
+
+// TimeRange is a window of time, such as the window of a batch job.
+// It is encoded as a JSON object with the "start", "end" and "duration" fields:
+//
+//	{"start":"2001-02-03T04:05:06Z","end":"2001-02-03T05:05:06Z","duration":"1h0m0s"}
+//
+// The start and the end are formatted in the layout of SetTimeFieldLayout,
+// and the duration is formatted by time.Duration.String.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the duration between the start and the end.
+func (r TimeRange) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+func (e *encodeState) appendTimeRange(r TimeRange) error {
+	e.WriteString(`{"start":`)
+	if err := e.appendTimeValue(r.Start); err != nil {
+		return err
+	}
+	e.WriteString(`,"end":`)
+	if err := e.appendTimeValue(r.End); err != nil {
+		return err
+	}
+	e.WriteString(`,"duration":`)
+	e.appendString(r.Duration().String())
+	e.WriteByte('}')
+	return nil
+}
@@ -2,28 +2,27 @@ package ctxlog
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"net"
+	"reflect"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
-var reservedFields = []string{
-	"time",
-	"level",
-	"file",
-	"line",
-	"message",
+// KV is a key-value pair of a field.
+type KV struct {
+	Key   string
+	Value any
 }
 
-type keyValue struct {
-	key   string
-	value any
-}
-
-type keyValues []keyValue
+type keyValues []KV
 
 var _ sort.Interface = keyValues(nil)
 
@@ -32,7 +31,7 @@ func (s keyValues) Len() int {
 }
 
 func (s keyValues) Less(i, j int) bool {
-	return s[i].key < s[j].key
+	return s[i].Key < s[j].Key
 }
 
 func (s keyValues) Swap(i, j int) {
@@ -42,10 +41,31 @@ func (s keyValues) Swap(i, j int) {
 type encodeState struct {
 	bytes.Buffer // accumulated output
 	scratch      [64]byte
-	kv           []keyValue
+	kv           []KV
+	sorter       keyValues // for sorting kv without allocation
+	tags         []string
+	line         []byte // formatted entry
+	timeLayout   string // layout of time.Time values, see Logger.SetTimeFieldLayout
+	utc          bool   // whether time.Time values are converted to UTC, see LUTC
+	depth        int    // depth of the nested maps being encoded
+	insertion    bool   // whether the fields are in the insertion order, see FieldOrderInsertion
+	entry        Entry
 	enc          *json.Encoder
 }
 
+var encodeStatePool = sync.Pool{
+	New: func() any {
+		return newEncodeState()
+	},
+}
+
+// resetEntry resets the buffer and the options for encoding entry.
+func (e *encodeState) resetEntry(entry *Entry) {
+	e.Reset()
+	e.timeLayout = entry.timeLayout
+	e.utc = entry.Flags&LUTC != 0
+}
+
 func newEncodeState() *encodeState {
 	e := new(encodeState)
 	e.enc = json.NewEncoder(&e.Buffer)
@@ -226,15 +246,31 @@ func (e *encodeState) appendTime(flags int, t time.Time) {
 			i += 7
 		}
 	}
-	if flags&LUTC != 0 {
+	_, offset := t.Zone()
+	if flags&LUTC != 0 || offset == 0 {
 		b[i] = 'Z'
 		i++
+	} else {
+		b[i] = '+'
+		if offset < 0 {
+			b[i] = '-'
+			offset = -offset
+		}
+		offset /= 60 // in minutes
+		b[i+1] = '0' + byte(offset/600)
+		b[i+2] = '0' + byte((offset/60)%10)
+		b[i+3] = ':'
+		b[i+4] = '0' + byte((offset%60)/10)
+		b[i+5] = '0' + byte(offset%10)
+		i += 6
 	}
 	e.Write((*b)[:i])
 }
 
 func (e *encodeState) appendAny(v any) error {
 	switch v := v.(type) {
+	case nil:
+		e.WriteString("null")
 	case int8:
 		e.appendInt(int64(v))
 	case int16:
@@ -263,6 +299,34 @@ func (e *encodeState) appendAny(v any) error {
 		return e.appendFloat32(v)
 	case float64:
 		return e.appendFloat64(v)
+	case []string:
+		if v == nil {
+			e.WriteString("null")
+		} else if len(v) == 0 {
+			e.WriteString("[]")
+		} else {
+			e.WriteByte('[')
+			e.appendString(v[0])
+			for _, vv := range v[1:] {
+				e.WriteByte(',')
+				e.appendString(vv)
+			}
+			e.WriteByte(']')
+		}
+	case map[string]string:
+		e.appendStringMap(v)
+	case Fields:
+		return e.appendMap(v)
+	case map[string]any:
+		return e.appendMap(v)
+	case ValidationError:
+		e.appendStringMap(v)
+	case net.IP:
+		e.appendString(v.String())
+	case *net.IPNet:
+		e.appendString(v.String())
+	case net.Addr:
+		e.appendString(v.String())
 	case []any:
 		if v == nil {
 			e.WriteString("null")
@@ -277,52 +341,456 @@ func (e *encodeState) appendAny(v any) error {
 			}
 			e.WriteByte(']')
 		}
+	case time.Time:
+		return e.appendTimeValue(v)
+	case time.Duration:
+		e.appendString(v.String())
+	case TimeRange:
+		return e.appendTimeRange(v)
+	case json.Marshaler:
+		return e.appendMarshalJSON(v)
+	case encoding.TextMarshaler:
+		return e.appendMarshalText(v)
+	case error:
+		if isNilPointer(v) {
+			e.WriteString("null")
+			return nil
+		}
+		e.appendString(v.Error())
 	default:
-		if err := e.enc.Encode(v); err != nil {
+		return e.appendReflect(v)
+	}
+	return nil
+}
+
+// appendReflect appends v using encoding/json.
+func (e *encodeState) appendReflect(v any) error {
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	// json.Encoder terminates each value with a newline, but it breaks the entry into lines.
+	e.Truncate(e.Len() - 1)
+	return nil
+}
+
+// appendMarshalJSON appends the result of v.MarshalJSON in compact form.
+// Like encoding/json, the result is validated, and HTML characters in it are escaped.
+func (e *encodeState) appendMarshalJSON(v json.Marshaler) error {
+	if isNilPointer(v) {
+		e.WriteString("null")
+		return nil
+	}
+	b, err := v.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("json: error calling MarshalJSON for type %T: %w", v, err)
+	}
+	start := e.Len()
+	if err := json.Compact(&e.Buffer, b); err != nil {
+		e.Truncate(start)
+		return fmt.Errorf("json: error calling MarshalJSON for type %T: %w", v, err)
+	}
+	if bytes.ContainsAny(e.Bytes()[start:], "<>&\u2028\u2029") {
+		compacted := append([]byte(nil), e.Bytes()[start:]...)
+		e.Truncate(start)
+		json.HTMLEscape(&e.Buffer, compacted)
+	}
+	return nil
+}
+
+// appendMarshalText appends the result of v.MarshalText as a JSON string.
+func (e *encodeState) appendMarshalText(v encoding.TextMarshaler) error {
+	if isNilPointer(v) {
+		e.WriteString("null")
+		return nil
+	}
+	b, err := v.MarshalText()
+	if err != nil {
+		return fmt.Errorf("json: error calling MarshalText for type %T: %w", v, err)
+	}
+	e.WriteByte('"')
+	e.appendRawString(string(b))
+	e.WriteByte('"')
+	return nil
+}
+
+// isNilPointer reports whether v is a nil pointer,
+// which encoding/json encodes as null without calling its methods.
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// appendTimeValue appends t in the layout of the logger.
+// If the layout is empty, t is formatted in RFC 3339 with nanoseconds, as encoding/json does.
+// Like the time of the entry, t is converted to UTC if the LUTC flag is set.
+func (e *encodeState) appendTimeValue(t time.Time) error {
+	if e.utc {
+		t = t.UTC()
+	}
+	layout := e.timeLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	e.appendTimeLayout(layout, t)
+	return nil
+}
+
+// appendTimeLayout appends t formatted with layout as a JSON string.
+func (e *encodeState) appendTimeLayout(layout string, t time.Time) {
+	b := t.AppendFormat(e.scratch[:0], layout)
+	for _, c := range b {
+		if c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			// the layout contains characters that need escaping.
+			e.appendString(string(b))
+			return
+		}
+	}
+	e.WriteByte('"')
+	e.Write(b)
+	e.WriteByte('"')
+}
+
+// maxDepth is the maximum depth of the nested maps, which guards against self-referential maps.
+const maxDepth = 32
+
+// appendMap appends v as a JSON object with the keys in sorted order.
+func (e *encodeState) appendMap(v map[string]any) error {
+	if v == nil {
+		e.WriteString("null")
+		return nil
+	}
+	if e.depth >= maxDepth {
+		return fmt.Errorf("ctxlog: exceeded the max depth %d of the nested maps", maxDepth)
+	}
+	e.depth++
+	defer func() { e.depth-- }()
+
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	e.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+		e.appendString(k)
+		e.WriteByte(':')
+		if err := e.appendAny(v[k]); err != nil {
 			return err
 		}
 	}
+	e.WriteByte('}')
 	return nil
 }
 
+func (e *encodeState) appendStringMap(v map[string]string) {
+	if v == nil {
+		e.WriteString("null")
+		return
+	}
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	e.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+		e.appendString(k)
+		e.WriteByte(':')
+		e.appendString(v[k])
+	}
+	e.WriteByte('}')
+}
+
 func (e *encodeState) appendFields(parent *mergedFields, fields Fields) error {
-	kv := e.kv[:0]
+	e.resetFields()
+	defer e.clearFields()
+	e.addFields(fields)
+	e.addMergedFields(parent, time.Time{})
+	return e.writeFields(e.normalizeFields(&normalizeOptions{}))
+}
+
+// resetFields starts collecting a new set of fields.
+func (e *encodeState) resetFields() {
+	e.kv = e.kv[:0]
+}
+
+// clearFields clears the collected fields for Garbage Collection.
+func (e *encodeState) clearFields() {
+	for i := range e.kv {
+		e.kv[i] = KV{}
+	}
+}
+
+// addFields adds fields.
+// Fields added earlier take precedence over fields added later.
+func (e *encodeState) addFields(fields Fields) {
 	for k, v := range fields {
-		kv = append(kv, keyValue{key: k, value: v})
+		e.kv = append(e.kv, KV{Key: k, Value: v})
 	}
+}
+
+// addMergedFields adds the fields from the context, the innermost first.
+// The fields that have expired at now are skipped.
+func (e *encodeState) addMergedFields(parent *mergedFields, now time.Time) {
 	for parent != nil {
-		for k, v := range parent.fields {
-			kv = append(kv, keyValue{key: k, value: v})
+		if parent.until.IsZero() || !now.After(parent.until) {
+			e.addFields(parent.fields)
 		}
 		parent = parent.parent
 	}
-	sort.Stable(keyValues(kv))
+}
+
+// addField adds a single field.
+func (e *encodeState) addField(key string, value any) {
+	e.kv = append(e.kv, KV{Key: key, Value: value})
+}
+
+// normalizeOptions controls normalizeFields.
+type normalizeOptions struct {
+	// merge combines the values of the same key.
+	// If merge is nil, the value collected first wins.
+	merge MergeFunc
+
+	// omitNil drops the fields with nil values.
+	omitNil bool
+
+	// maxFields is the maximum number of the fields.
+	// If it is zero, the number of the fields is unlimited.
+	maxFields int
+
+	// names is the keys of the reserved fields.
+	names FieldNames
+
+	// insertion keeps the fields in the collected order instead of sorting them.
+	insertion bool
+}
+
+// normalizeFields sorts the collected fields by key and removes duplicated keys.
+// If the same key is collected more than once, the values are combined by opts.merge.
+// The keys that conflict with the reserved fields are prefixed with "field.".
+func (e *encodeState) normalizeFields(opts *normalizeOptions) []KV {
+	e.insertion = opts.insertion
+	merge := opts.merge
+	names := opts.names.withDefaults()
+	kv := e.kv
+	var truncated int
+	if opts.maxFields > 0 && len(kv) > opts.maxFields {
+		kv, truncated = truncateFields(kv, opts.maxFields)
+	}
+	if opts.insertion {
+		kv = dedupFields(kv, merge)
+		n := 0
+		for _, f := range kv {
+			if opts.omitNil && isNil(f.Value) {
+				continue
+			}
+			kv[n] = KV{Key: reservedKey(f.Key, &names), Value: f.Value}
+			n++
+		}
+		if truncated > 0 {
+			return e.insertField(kv[:n], "fields_truncated", truncated)
+		}
+		return kv[:n]
+	}
+	// sort through the pointer in e, because converting the slice to sort.Interface allocates.
+	e.sorter = kv
+	sort.Stable(&e.sorter)
+	e.sorter = nil
+
+	n := 0
+	for i := 0; i < len(kv); {
+		key, value := kv[i].Key, kv[i].Value
+		j := i + 1
+		for j < len(kv) && kv[j].Key == key {
+			j++
+		}
+		if merge != nil && j-i > 1 {
+			value = kv[j-1].Value
+			for k := j - 2; k >= i; k-- {
+				value = merge(key, value, kv[k].Value)
+			}
+		}
+		i = j
 
-	for i, pair := range kv {
-		if i > 0 && kv[i-1].key == pair.key {
+		if opts.omitNil && isNil(value) {
 			continue
 		}
-		e.WriteByte(',')
-		e.WriteByte('"')
-		for _, k := range reservedFields {
-			if pair.key == k {
-				e.appendRawString("field.")
+		kv[n] = KV{Key: reservedKey(key, &names), Value: value}
+		n++
+	}
+	if truncated > 0 {
+		return e.insertField(kv[:n], "fields_truncated", truncated)
+	}
+	return kv[:n]
+}
+
+// dedupFields removes duplicated keys of kv without changing the order of the first occurrences.
+// If the same key is collected more than once, the values are combined by merge
+// in the same order as normalizeFields.
+func dedupFields(kv []KV, merge MergeFunc) []KV {
+	n := 0
+	for i := 0; i < len(kv); i++ {
+		key := kv[i].Key
+		dup := false
+		for _, f := range kv[:n] {
+			if f.Key == key {
+				dup = true
 				break
 			}
 		}
-		e.appendRawString(pair.key)
-		e.WriteByte('"')
-		e.WriteByte(':')
-		if err := e.appendAny(pair.value); err != nil {
-			return err
+		if dup {
+			continue
+		}
+
+		value := kv[i].Value
+		if merge != nil {
+			// combine the values from the lowest precedence.
+			last := -1
+			for j := len(kv) - 1; j > i; j-- {
+				if kv[j].Key != key {
+					continue
+				}
+				if last < 0 {
+					value = kv[j].Value
+				} else {
+					value = merge(key, value, kv[j].Value)
+				}
+				last = j
+			}
+			if last >= 0 {
+				value = merge(key, value, kv[i].Value)
+			}
 		}
+		kv[n] = KV{Key: key, Value: value}
+		n++
 	}
+	return kv[:n]
+}
+
+// truncateFields keeps the first max distinct keys of kv, which have the highest precedence,
+// and drops the others.
+// It returns the kept fields and the number of the dropped keys.
+func truncateFields(kv []KV, max int) ([]KV, int) {
+	keep := make(map[string]bool, max)
+	dropped := 0
+	for _, f := range kv {
+		if _, ok := keep[f.Key]; ok {
+			continue
+		}
+		if len(keep) < max {
+			keep[f.Key] = true
+		} else {
+			keep[f.Key] = false
+			dropped++
+		}
+	}
+	if dropped == 0 {
+		return kv, 0
+	}
+
+	n := 0
+	for _, f := range kv {
+		if keep[f.Key] {
+			kv[n] = f
+			n++
+		}
+	}
+	return kv[:n], dropped
+}
+
+// isNil reports whether v is nil or a typed nil.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// reservedKey returns the key prefixed with "field." if it conflicts with the reserved fields.
+func reservedKey(key string, names *FieldNames) string {
+	if names.reserved(key) {
+		return "field." + key
+	}
+	return key
+}
 
-	// fill with nil for Garbage Collection
-	for i := range kv {
-		kv[i].key = ""
-		kv[i].value = nil
+// appendKey appends key as a JSON string.
+// If key is in reserved, it is prefixed with "field.".
+func (e *encodeState) appendKey(key string, reserved []string) {
+	e.WriteByte('"')
+	for _, k := range reserved {
+		if key == k {
+			e.appendRawString("field.")
+			break
+		}
+	}
+	e.appendRawString(key)
+	e.WriteByte('"')
+}
+
+// encodeErrorPlaceholder is written in place of the value of a field that fails to encode.
+const encodeErrorPlaceholder = "!ENCODE_ERROR"
+
+// appendFieldOr appends the value of the field key like appendField.
+// If it fails, it discards the partial output, appends placeholder instead
+// and returns the error annotated with the key.
+func (e *encodeState) appendFieldOr(key string, v any, placeholder string) error {
+	n := e.Len()
+	if err := e.appendField(key, v); err != nil {
+		e.Truncate(n)
+		e.WriteString(placeholder)
+		return fmt.Errorf("ctxlog: failed to encode the field %q: %w", key, err)
 	}
-	e.kv = kv
 	return nil
 }
+
+// writeFields writes the fields.
+// The keys in reserved are prefixed with "field.",
+// in addition to the keys already prefixed by normalizeFields.
+// The value of a field that fails to encode is replaced with encodeErrorPlaceholder,
+// and the errors are joined and returned after all the fields are written.
+func (e *encodeState) writeFields(fields []KV, reserved ...string) error {
+	var errs []error
+	for _, f := range fields {
+		e.WriteByte(',')
+		e.appendKey(f.Key, reserved)
+		e.WriteByte(':')
+		if err := e.appendFieldOr(f.Key, f.Value, `"`+encodeErrorPlaceholder+`"`); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeNestedFields writes the fields as a nested object named root.
+func (e *encodeState) writeNestedFields(root string, fields []KV) error {
+	e.WriteByte(',')
+	e.appendString(root)
+	e.WriteString(":{")
+	var errs []error
+	for i, f := range fields {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+		e.appendKey(f.Key, nil)
+		e.WriteByte(':')
+		if err := e.appendFieldOr(f.Key, f.Value, `"`+encodeErrorPlaceholder+`"`); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	e.WriteByte('}')
+	return errors.Join(errs...)
+}
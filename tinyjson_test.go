@@ -1,8 +1,11 @@
 package ctxlog
 
 import (
+	"errors"
 	"math"
+	"net"
 	"testing"
+	"time"
 )
 
 func TestAppendAny(t *testing.T) {
@@ -109,6 +112,130 @@ func TestAppendAny(t *testing.T) {
 			in:   []any{"string", "array"},
 			want: `["string","array"]`,
 		},
+
+		// map
+		{
+			in:   map[string]string(nil),
+			want: `null`,
+		},
+		{
+			in:   map[string]string{},
+			want: `{}`,
+		},
+		{
+			in:   map[string]string{"foo": "bar", "hoge": "<fuga>", "abc": "def"},
+			want: `{"abc":"def","foo":"bar","hoge":"\u003cfuga\u003e"}`,
+		},
+
+		{
+			in:   ValidationError{"name": "is required", "age": "must be positive"},
+			want: `{"age":"must be positive","name":"is required"}`,
+		},
+
+		// network
+		{
+			in:   net.IPv4(10, 0, 0, 1),
+			want: `"10.0.0.1"`,
+		},
+		{
+			in:   net.ParseIP("2001:db8::1"),
+			want: `"2001:db8::1"`,
+		},
+		{
+			in:   &net.IPNet{IP: net.IPv4(192, 168, 0, 0), Mask: net.CIDRMask(16, 32)},
+			want: `"192.168.0.0/16"`,
+		},
+		{
+			in:   &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080},
+			want: `"127.0.0.1:8080"`,
+		},
+
+		// errors
+		{
+			in:   errors.New("not found"),
+			want: `"not found"`,
+		},
+		{
+			in:   errors.New("\"quoted\"\n<script>"),
+			want: `"\"quoted\"\n\u003cscript\u003e"`,
+		},
+		{
+			in:   (*testNilError)(nil),
+			want: `null`,
+		},
+
+		// time
+		{
+			in:   time.Time{},
+			want: `"0001-01-01T00:00:00Z"`,
+		},
+		{
+			in:   time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC),
+			want: `"2001-02-03T04:05:06.123456789Z"`,
+		},
+		{
+			in:   time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("JST", 9*60*60)),
+			want: `"2001-02-03T04:05:06+09:00"`,
+		},
+		{
+			in:   1500 * time.Microsecond,
+			want: `"1.5ms"`,
+		},
+		{
+			in:   1500 * time.Millisecond,
+			want: `"1.5s"`,
+		},
+		{
+			in:   2*time.Hour + 30*time.Minute,
+			want: `"2h30m0s"`,
+		},
+		{
+			in:   time.Duration(0),
+			want: `"0s"`,
+		},
+		{
+			in: TimeRange{
+				Start: time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC),
+				End:   time.Date(2001, 2, 3, 5, 35, 6, 0, time.UTC),
+			},
+			want: `{"start":"2001-02-03T04:05:06Z","end":"2001-02-03T05:35:06Z","duration":"1h30m0s"}`,
+		},
+
+		// nested objects
+		{
+			in:   Fields{"b": 1, "a": map[string]any{"d": []any{true, nil}, "c": "<x>"}},
+			want: `{"a":{"c":"\u003cx\u003e","d":[true,null]},"b":1}`,
+		},
+		{
+			in:   map[string]any{},
+			want: `{}`,
+		},
+		{
+			in:   Fields(nil),
+			want: `null`,
+		},
+
+		// marshalers
+		{
+			in:   testJSONMarshaler("{\n  \"color\": \"<red>\"\n}\n"),
+			want: `{"color":"\u003cred\u003e"}`,
+		},
+		{
+			in:   testJSONMarshaler(`"custom"`),
+			want: `"custom"`,
+		},
+		{
+			in:   (*testPointerMarshaler)(nil),
+			want: `null`,
+		},
+		{
+			in:   testTextMarshaler("a\"b\n<c>"),
+			want: `"a\"b\n\u003cc\u003e"`,
+		},
+		{
+			in:   LevelWarn,
+			want: `"warn"`,
+		},
 	}
 
 	e := newEncodeState()
@@ -124,3 +251,84 @@ func TestAppendAny(t *testing.T) {
 		}
 	}
 }
+
+type testJSONMarshaler string
+
+func (m testJSONMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(m), nil
+}
+
+type testTextMarshaler string
+
+func (m testTextMarshaler) MarshalText() ([]byte, error) {
+	return []byte(m), nil
+}
+
+type testPointerMarshaler struct{}
+
+func (m *testPointerMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`"pointer"`), nil
+}
+
+type testNilError struct {
+	msg string
+}
+
+func (e *testNilError) Error() string { return e.msg }
+
+func (e *testNilError) StackTrace() []string { return []string{e.msg} }
+
+func TestAppendAny_InvalidMarshalJSON(t *testing.T) {
+	e := newEncodeState()
+	e.WriteString(`{"message":"hello"`)
+	if err := e.writeFields([]KV{{Key: "broken", Value: testJSONMarshaler(`{"unterminated":`)}}); err == nil {
+		t.Error("want an error, got nil")
+	}
+	e.WriteByte('}')
+	want := `{"message":"hello","broken":"!ENCODE_ERROR"}`
+	if got := e.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAppendAny_SelfReference(t *testing.T) {
+	m := map[string]any{}
+	m["self"] = m
+
+	e := newEncodeState()
+	if err := e.appendAny(m); err == nil {
+		t.Error("want an error, got nil")
+	}
+	if e.depth != 0 {
+		t.Errorf("unexpected depth: got %d, want 0", e.depth)
+	}
+}
+
+func TestAppendAny_TimeUTC(t *testing.T) {
+	e := newEncodeState()
+	e.resetEntry(&Entry{Flags: LUTC})
+	in := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("JST", 9*60*60))
+	if err := e.appendAny(in); err != nil {
+		t.Fatal(err)
+	}
+	want := `"2001-02-02T19:05:06Z"`
+	if got := e.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAppendAny_TimeRangeLayout(t *testing.T) {
+	e := newEncodeState()
+	e.timeLayout = TimeFieldMillis
+	r := TimeRange{
+		Start: time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC),
+		End:   time.Date(2001, 2, 3, 4, 5, 7, 500000000, time.UTC),
+	}
+	if err := e.appendAny(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"start":"2001-02-03T04:05:06.000Z","end":"2001-02-03T04:05:07.500Z","duration":"1.5s"}`
+	if got := e.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package ctxlog
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// ValidationError is a set of validation errors.
+// It maps the name of an invalid input field to the error message.
+// It is encoded as a JSON object.
+type ValidationError map[string]string
+
+// Error implements error.
+func (e ValidationError) Error() string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(k)
+		b.WriteString(": ")
+		b.WriteString(e[k])
+	}
+	return b.String()
+}
+
+// WithValidationErrors returns a copy of parent that carries errs as the "validation_errors" field.
+func WithValidationErrors(parent context.Context, errs ValidationError) context.Context {
+	return With(parent, Fields{"validation_errors": errs})
+}
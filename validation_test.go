@@ -0,0 +1,35 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidationError(t *testing.T) {
+	errs := ValidationError{"name": "is required", "age": "must be positive"}
+	if got, want := errs.Error(), "age: must be positive; name: is required"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithValidationErrors(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, "", 0)
+
+	ctx := WithValidationErrors(context.Background(), ValidationError{"name": "is required"})
+	l.Warn(ctx, "invalid request", nil)
+
+	var got struct {
+		ValidationErrors map[string]string `json:"validation_errors"`
+	}
+	t.Log(buf.String())
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"name": "is required"}; !reflect.DeepEqual(got.ValidationErrors, want) {
+		t.Errorf("got %v, want %v", got.ValidationErrors, want)
+	}
+}
@@ -0,0 +1,116 @@
+package ctxlog
+
+// With returns a child logger that emits fields with every entry,
+// e.g. for a component that always logs {"component":"billing"}.
+//
+// The bound fields have lower precedence than the context fields and the per-call fields:
+//
+//	per-call fields > context fields > bound fields
+//
+// The child shares the output, the flags, the prefix and the level with l,
+// and follows the changes of them on l until they are set on the child.
+// Setting them on the child doesn't affect l.
+// The other settings, such as the formatter and the default fields, are copied from l
+// when With is called.
+func (l *Logger) With(fields Fields) *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	child := &Logger{
+		parent: l,
+		bound: &mergedFields{
+			parent: l.bound,
+			fields: fields,
+		},
+		id:          l.id,
+		merge:       l.merge,
+		nilPolicy:   l.nilPolicy,
+		maxFields:   l.maxFields,
+		formatter:   l.formatter,
+		errHandler:  l.errHandler,
+		extractor:   l.extractor,
+		hooks:       l.hooks[:len(l.hooks):len(l.hooks)],
+		processors:  l.processors[:len(l.processors):len(l.processors)],
+		redactKeys:  l.redactKeys,
+		name:        l.name,
+		nameKey:     l.nameKey,
+		fieldOrder:  l.fieldOrder,
+		slogHandler: l.slogHandler,
+		sampler:     l.sampler,
+		defaults:    l.defaults,
+		timeField:   l.timeField,
+		timeFormat:  l.timeFormat,
+		levelFormat: l.levelFormat,
+		colorLevel:  l.colorLevel,
+		names:       l.names,
+		eventLevel:  l.eventLevel,
+		fatalCode:   l.fatalCode,
+		callerSkip:  l.callerSkip,
+		stackPolicy: l.stackPolicy,
+		stackLevel:  l.stackLevel,
+		fingerprint: l.fingerprint,
+		priority:    l.priority,
+		auditOut:    l.auditOut,
+		auditFormat: l.auditFormat,
+		eventOut:    l.eventOut,
+		eventFormat: l.eventFormat,
+		rootKey:     l.rootKey,
+	}
+	child.fieldStats.Store(l.fieldStats.Load())
+	child.inheritOut.Store(true)
+	child.updateDiscard()
+	return child
+}
+
+// Named returns a child logger that emits the name as the "logger" field with every entry,
+// e.g. for telling which subsystem produced the entry.
+// The names of the named loggers are joined with dots:
+//
+//	logger.Named("db").Named("pool") // {"logger":"db.pool"}
+//
+// Like With, the child shares the output, the flags, the prefix and the level with l.
+// The name field has the same precedence as the fields bound by With.
+// See SetNameKey for changing the key.
+func (l *Logger) Named(name string) *Logger {
+	child := l.With(nil)
+	if parent := l.Name(); parent != "" {
+		name = parent + "." + name
+	}
+	child.name = name
+	return child
+}
+
+// Name returns the name of the logger set by Named.
+func (l *Logger) Name() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.name
+}
+
+// NameKey returns the key of the field of the name set by Named.
+func (l *Logger) NameKey() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.nameKey == "" {
+		return "logger"
+	}
+	return l.nameKey
+}
+
+// SetNameKey sets the key of the field of the name set by Named.
+// The default is "logger".
+// The loggers derived by Named and With after the call use the key.
+func (l *Logger) SetNameKey(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nameKey = key
+}
+
+// root returns the logger that l derives from by With, or l itself.
+// The writes to the shared output are serialized by the mutex of the root.
+func (l *Logger) root() *Logger {
+	for l.parent != nil {
+		l = l.parent
+	}
+	return l
+}
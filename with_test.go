@@ -0,0 +1,130 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestLoggerWith(t *testing.T) {
+	buf := new(bytes.Buffer)
+	parent := New(buf, "", 0)
+	child := parent.With(Fields{"component": "billing", "user": "bound", "request_id": "bound"})
+
+	ctx := With(context.Background(), Fields{"user": "context", "request_id": "context"})
+	child.Info(ctx, "hello", Fields{"request_id": "call"})
+	want := `{"level":"info","message":"hello","component":"billing","request_id":"call","user":"context"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+
+	// the parent doesn't emit the bound fields.
+	buf.Reset()
+	parent.Info(context.Background(), "hello", nil)
+	if got, want := buf.String(), `{"level":"info","message":"hello"}`+"\n"; got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+
+	// With on the child layers the fields.
+	buf.Reset()
+	grandchild := child.With(Fields{"component": "invoice"})
+	grandchild.Info(context.Background(), "hello", nil)
+	want = `{"level":"info","message":"hello","component":"invoice","request_id":"bound","user":"bound"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+	if got := grandchild.BaseFields(); got["component"] != "invoice" || got["user"] != "bound" {
+		t.Errorf("unexpected base fields: %v", got)
+	}
+}
+
+func TestLoggerWith_Settings(t *testing.T) {
+	buf := new(bytes.Buffer)
+	parent := New(buf, "", 0)
+	child := parent.With(Fields{"component": "billing"})
+
+	// the child follows the changes on the parent.
+	parent.SetLevel(LevelWarn)
+	parent.SetPrefix("app: ")
+	if got := child.Level(); got != LevelWarn {
+		t.Errorf("unexpected level: got %v, want %v", got, LevelWarn)
+	}
+	if got := child.Prefix(); got != "app: " {
+		t.Errorf("unexpected prefix: got %q, want %q", got, "app: ")
+	}
+
+	// the changes on the child don't affect the parent.
+	other := new(bytes.Buffer)
+	child.SetLevel(LevelDebug)
+	child.SetOutput(other)
+	if got := parent.Level(); got != LevelWarn {
+		t.Errorf("unexpected level of the parent: got %v, want %v", got, LevelWarn)
+	}
+	child.Debug(context.Background(), "hello", nil)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output of the parent: %q", buf.String())
+	}
+	if got, want := other.String(), `{"level":"debug","message":"app: hello","component":"billing"}`+"\n"; got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
+
+func TestLoggerNamed(t *testing.T) {
+	buf := new(bytes.Buffer)
+	root := New(buf, "", 0)
+	router := root.Named("http").Named("router")
+
+	router.Info(context.Background(), "hello", Fields{"path": "/"})
+	want := `{"level":"info","message":"hello","logger":"http.router","path":"/"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+
+	// the named logger follows the level of the root.
+	buf.Reset()
+	root.SetLevel(LevelWarn)
+	router.Info(context.Background(), "hello", nil)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	// the key conflicting with the reserved fields is prefixed.
+	buf.Reset()
+	root.SetNameKey("level")
+	root.Named("db").Warn(context.Background(), "hello", nil)
+	want = `{"level":"warn","message":"hello","field.level":"db"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output: got %q, want %q", got, want)
+	}
+}
+
+func TestLoggerWith_Race(t *testing.T) {
+	buf := new(bytes.Buffer)
+	parent := New(buf, "", 0)
+	child := parent.With(Fields{"component": "billing"})
+
+	var wg sync.WaitGroup
+	for _, l := range []*Logger{parent, child} {
+		wg.Add(1)
+		go func(l *Logger) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Info(context.Background(), "hello", nil)
+			}
+		}(l)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			parent.SetLevel(LevelInfo)
+			child.SetFlags(0)
+		}
+	}()
+	wg.Wait()
+
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 200 {
+		t.Errorf("unexpected number of lines: got %d, want 200", got)
+	}
+}